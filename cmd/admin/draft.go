@@ -0,0 +1,298 @@
+// Package admin reads and checks the recipe editor's form. The handlers in
+// main wire it to Datastar and the recipe registry.
+package admin
+
+import (
+	"cooking-with-datastar/cmd/recipes"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Draft is a recipe as it is being edited. CookTime and Quantities keep the
+// text as typed so that a duration or quantity which does not parse yet is not
+// thrown away.
+type Draft struct {
+	// Original is the name the recipe was loaded with, empty for a new one.
+	Original string
+	Recipe   recipes.Recipe
+	CookTime string
+	// Quantities has one entry per ingredient, empty when the quantity is read
+	// from the description.
+	Quantities []string
+}
+
+// NewDraft starts editing an existing recipe.
+func NewDraft(r recipes.Recipe) Draft {
+	quantities := []string{}
+	for _, i := range r.Ingredients {
+		if i.Quantity == nil {
+			quantities = append(quantities, "")
+			continue
+		}
+
+		quantities = append(quantities, i.Quantity.String())
+	}
+
+	return Draft{r.Name, r, r.CookingMethod.CookTime.String(), quantities}
+}
+
+// ParseForm reads a draft from the editor form. Dependencies are sent as task
+// indexes, so renaming a task keeps the tasks that depend on it.
+func ParseForm(form url.Values) Draft {
+	d := Draft{
+		Original: form.Get("original"),
+		CookTime: form.Get("method-cook-time"),
+	}
+
+	d.Recipe.Name = form.Get("name")
+	d.Recipe.ImageSrc = form.Get("image-src")
+
+	// Unchecked checkboxes are left out of the form, so the optional ones
+	// are sent as ingredient indexes too.
+	ingredientNames := form["ingredient-name"]
+	ingredientDescriptions := form["ingredient-description"]
+	ingredientGroups := form["ingredient-group"]
+	ingredientQuantities := form["ingredient-quantity"]
+	ingredientAisles := form["ingredient-aisle"]
+	for i, name := range ingredientNames {
+		quantity := strings.TrimSpace(at(ingredientQuantities, i))
+		d.Quantities = append(d.Quantities, quantity)
+
+		ingredient := recipes.Ingredient{
+			Name:        name,
+			Description: at(ingredientDescriptions, i),
+			Optional:    slices.Contains(form["ingredient-optional"], strconv.Itoa(i)),
+			Group:       at(ingredientGroups, i),
+			Aisle:       at(ingredientAisles, i),
+		}
+
+		q, err := recipes.ParseExactQuantity(quantity)
+		if err == nil && quantity != "" {
+			ingredient.Quantity = &q
+		}
+
+		d.Recipe.Ingredients = append(d.Recipe.Ingredients, ingredient)
+	}
+
+	taskNames := form["task-name"]
+	taskDescriptions := form["task-description"]
+	for i, name := range taskNames {
+		dependencies := []string{}
+		for _, v := range form[fmt.Sprintf("task-dependencies-%d", i)] {
+			j, err := strconv.Atoi(v)
+			if err != nil || j < 0 || j >= len(taskNames) || j == i {
+				continue
+			}
+
+			dependencies = append(dependencies, taskNames[j])
+		}
+
+		d.Recipe.Tasks = append(d.Recipe.Tasks, recipes.Task{Name: name, Description: at(taskDescriptions, i), Dependencies: dependencies})
+	}
+
+	cookTime, _ := time.ParseDuration(d.CookTime)
+	d.Recipe.CookingMethod = recipes.CookingMethod{
+		Name:        form.Get("method-name"),
+		Description: form.Get("method-description"),
+		CookTime:    cookTime,
+	}
+
+	return d
+}
+
+func at(values []string, i int) string {
+	if i < len(values) {
+		return values[i]
+	}
+
+	return ""
+}
+
+// The lists an editor operation can change.
+const (
+	Ingredients = "ingredients"
+	Tasks       = "tasks"
+)
+
+// The operations on a list.
+const (
+	Add    = "add"
+	Remove = "remove"
+	Up     = "up"
+	Down   = "down"
+)
+
+// Apply adds, removes or moves an ingredient or task. Removing a task also
+// removes it from the dependencies of the others.
+func (d *Draft) Apply(op string, list string, index int) error {
+	switch list {
+	case Ingredients:
+		items, err := apply(d.Recipe.Ingredients, op, index, recipes.Ingredient{})
+		if err != nil {
+			return err
+		}
+
+		// A draft built in code may not have a quantity for every ingredient.
+		quantities := slices.Clone(d.Quantities)
+		for len(quantities) < len(d.Recipe.Ingredients) {
+			quantities = append(quantities, "")
+		}
+
+		quantities, err = apply(quantities, op, index, "")
+		if err != nil {
+			return err
+		}
+
+		d.Recipe.Ingredients = items
+		d.Quantities = quantities
+
+	case Tasks:
+		var removed string
+		if op == Remove && index >= 0 && index < len(d.Recipe.Tasks) {
+			removed = d.Recipe.Tasks[index].Name
+		}
+
+		items, err := apply(d.Recipe.Tasks, op, index, recipes.Task{Dependencies: []string{}})
+		if err != nil {
+			return err
+		}
+
+		d.Recipe.Tasks = items
+
+		if removed != "" {
+			for i, t := range d.Recipe.Tasks {
+				d.Recipe.Tasks[i].Dependencies = slices.DeleteFunc(slices.Clone(t.Dependencies), func(dep string) bool {
+					return dep == removed
+				})
+			}
+		}
+
+	default:
+		return fmt.Errorf("unknown list %q", list)
+	}
+
+	return nil
+}
+
+func apply[T any](items []T, op string, index int, empty T) ([]T, error) {
+	if op == Add {
+		return append(items, empty), nil
+	}
+
+	if index < 0 || index >= len(items) {
+		return nil, fmt.Errorf("index %d out of range", index)
+	}
+
+	items = slices.Clone(items)
+
+	switch op {
+	case Remove:
+		return slices.Delete(items, index, index+1), nil
+
+	case Up:
+		if index > 0 {
+			items[index-1], items[index] = items[index], items[index-1]
+		}
+
+		return items, nil
+
+	case Down:
+		if index < len(items)-1 {
+			items[index+1], items[index] = items[index], items[index+1]
+		}
+
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("unknown operation %q", op)
+}
+
+// Check lists every problem that would stop the draft being saved. The
+// recipe's own validation catches badly formed and duplicate names and
+// dependency cycles.
+func (d Draft) Check() []error {
+	errs := []error{}
+
+	err := recipes.ValidateName("recipe", d.Recipe.Name)
+	if err != nil {
+		errs = append(errs, err)
+	} else if d.Recipe.Name != d.Original {
+		_, err := recipes.ParseRecipe(d.Recipe.Name)
+		if err == nil {
+			errs = append(errs, fmt.Errorf("a recipe named %q already exists", d.Recipe.Name))
+		}
+	}
+
+	err = recipes.ValidateName("cooking method", d.Recipe.CookingMethod.Name)
+	if err != nil {
+		errs = append(errs, err)
+	} else if slices.ContainsFunc(d.Recipe.Tasks, func(t recipes.Task) bool { return t.Name == d.Recipe.CookingMethod.Name }) {
+		errs = append(errs, fmt.Errorf("cooking method %q has the same name as a task", d.Recipe.CookingMethod.Name))
+	}
+
+	cookTime, err := time.ParseDuration(d.CookTime)
+	if err != nil || cookTime < 0 {
+		errs = append(errs, fmt.Errorf("cook time %q must be a duration such as 45m or 1h30m, or 0s for a recipe that is not cooked", d.CookTime))
+	}
+
+	for i, q := range d.Quantities {
+		if q == "" {
+			continue
+		}
+
+		_, err := recipes.ParseExactQuantity(q)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ingredient %d: %w", i+1, err))
+		}
+	}
+
+	err = recipes.Validate(d.Recipe.Ingredients, d.Recipe.Tasks)
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = append(errs, joined.Unwrap()...)
+	} else if err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// Build is the draft ready to save. Translations of the recipe it was loaded
+// from are kept for the names that are still there.
+func (d Draft) Build() recipes.Recipe {
+	r := d.Recipe
+
+	original, err := recipes.ParseRecipe(d.Original)
+	if err != nil || len(original.Translations) == 0 {
+		return r
+	}
+
+	names := map[string]bool{r.CookingMethod.Name: true}
+	for _, i := range r.Ingredients {
+		names[i.Name] = true
+	}
+	for _, t := range r.Tasks {
+		names[t.Name] = true
+	}
+
+	keep := func(m map[string]string) map[string]string {
+		kept := map[string]string{}
+		for k, v := range m {
+			if names[k] {
+				kept[k] = v
+			}
+		}
+
+		return kept
+	}
+
+	r.Translations = map[string]recipes.Translation{}
+	for locale, t := range original.Translations {
+		r.Translations[locale] = recipes.Translation{Title: t.Title, Labels: keep(t.Labels), Descriptions: keep(t.Descriptions)}
+	}
+
+	return r
+}
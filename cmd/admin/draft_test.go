@@ -0,0 +1,247 @@
+package admin_test
+
+import (
+	"cooking-with-datastar/cmd/admin"
+	"cooking-with-datastar/cmd/recipes"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func toastForm() url.Values {
+	return url.Values{
+		"name":                   {"toast"},
+		"ingredient-name":        {"bread", "butter"},
+		"ingredient-description": {"2 slices of bread", "1 tablespoon butter"},
+		"task-name":              {"slice", "toast", "spread"},
+		"task-description":       {"Slice the bread", "Toast the slices", "Spread the butter"},
+		"task-dependencies-1":    {"0"},
+		"task-dependencies-2":    {"1"},
+		"method-name":            {"serve"},
+		"method-description":     {"Serve while warm"},
+		"method-cook-time":       {"90s"},
+	}
+}
+
+func taskNames(tasks []recipes.Task) []string {
+	names := []string{}
+	for _, t := range tasks {
+		names = append(names, t.Name)
+	}
+
+	return names
+}
+
+func TestParseForm(t *testing.T) {
+	d := admin.ParseForm(toastForm())
+
+	if errs := d.Check(); len(errs) > 0 {
+		t.Fatalf("want no errors, got %v", errs)
+	}
+
+	if d.Recipe.CookingMethod.CookTime != 90*time.Second {
+		t.Logf("want '%s', got '%s'", 90*time.Second, d.Recipe.CookingMethod.CookTime)
+		t.Fail()
+	}
+
+	if !slices.Equal(d.Recipe.Tasks[2].Dependencies, []string{"toast"}) {
+		t.Logf("want %v, got %v", []string{"toast"}, d.Recipe.Tasks[2].Dependencies)
+		t.Fail()
+	}
+}
+
+func TestParseFormIngredientOptions(t *testing.T) {
+	form := toastForm()
+	form["ingredient-name"] = []string{"bread", "butter", "jam"}
+	form["ingredient-description"] = []string{"2 slices of bread", "1 tablespoon butter", "1 tablespoon jam"}
+	form["ingredient-group"] = []string{"", "spread", "spread"}
+	form["ingredient-optional"] = []string{"2"}
+	form["ingredient-aisle"] = []string{"baking", "dairy", ""}
+
+	d := admin.ParseForm(form)
+
+	if errs := d.Check(); len(errs) > 0 {
+		t.Fatalf("want no errors, got %v", errs)
+	}
+
+	expected := []recipes.Ingredient{
+		{Name: "bread", Description: "2 slices of bread", Aisle: "baking"},
+		{Name: "butter", Description: "1 tablespoon butter", Group: "spread", Aisle: "dairy"},
+		{Name: "jam", Description: "1 tablespoon jam", Optional: true, Group: "spread"},
+	}
+	if !slices.Equal(d.Recipe.Ingredients, expected) {
+		t.Logf("want %+v, got %+v", expected, d.Recipe.Ingredients)
+		t.Fail()
+	}
+}
+
+func TestParseFormQuantities(t *testing.T) {
+	form := toastForm()
+	form["ingredient-quantity"] = []string{"2 slices", ""}
+
+	d := admin.ParseForm(form)
+
+	if errs := d.Check(); len(errs) > 0 {
+		t.Fatalf("want no errors, got %v", errs)
+	}
+
+	q := d.Recipe.Ingredients[0].Quantity
+	if q == nil || *q != (recipes.Quantity{Amount: 2, Unit: "slice"}) {
+		t.Logf("want '2 slices', got '%v'", q)
+		t.Fail()
+	}
+
+	if d.Recipe.Ingredients[1].Quantity != nil {
+		t.Logf("want no quantity, got '%v'", d.Recipe.Ingredients[1].Quantity)
+		t.Fail()
+	}
+
+	err := d.Apply(admin.Down, admin.Ingredients, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(d.Quantities, []string{"", "2 slices"}) {
+		t.Logf("want quantities to move with their ingredient, got %v", d.Quantities)
+		t.Fail()
+	}
+}
+
+func TestParseFormFollowsRenames(t *testing.T) {
+	form := toastForm()
+	form["task-name"] = []string{"slice", "grill", "spread"}
+
+	d := admin.ParseForm(form)
+
+	if !slices.Equal(d.Recipe.Tasks[2].Dependencies, []string{"grill"}) {
+		t.Logf("want %v, got %v", []string{"grill"}, d.Recipe.Tasks[2].Dependencies)
+		t.Fail()
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name         string
+		op           string
+		index        int
+		tasks        []string
+		dependencies [][]string
+	}{
+		{"add", admin.Add, 0, []string{"slice", "toast", "spread", ""}, [][]string{{}, {"slice"}, {"toast"}, {}}},
+		{"remove", admin.Remove, 1, []string{"slice", "spread"}, [][]string{{}, {}}},
+		{"up", admin.Up, 2, []string{"slice", "spread", "toast"}, [][]string{{}, {"toast"}, {"slice"}}},
+		{"up at the top", admin.Up, 0, []string{"slice", "toast", "spread"}, [][]string{{}, {"slice"}, {"toast"}}},
+		{"down", admin.Down, 0, []string{"toast", "slice", "spread"}, [][]string{{"slice"}, {}, {"toast"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := admin.ParseForm(toastForm())
+
+			err := d.Apply(tt.op, admin.Tasks, tt.index)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := taskNames(d.Recipe.Tasks); !slices.Equal(got, tt.tasks) {
+				t.Logf("want %v, got %v", tt.tasks, got)
+				t.Fail()
+			}
+
+			for i, task := range d.Recipe.Tasks {
+				if !slices.Equal(task.Dependencies, tt.dependencies[i]) {
+					t.Logf("%s: want %v, got %v", task.Name, tt.dependencies[i], task.Dependencies)
+					t.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestApplyRejects(t *testing.T) {
+	d := admin.ParseForm(toastForm())
+
+	for _, err := range []error{
+		d.Apply(admin.Remove, admin.Ingredients, 5),
+		d.Apply("shuffle", admin.Tasks, 0),
+		d.Apply(admin.Add, "steps", 0),
+	} {
+		if err == nil {
+			t.Log("want an error, got none")
+			t.Fail()
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(form url.Values)
+		want   string
+	}{
+		{"cycle", func(form url.Values) { form["task-dependencies-0"] = []string{"2"} }, "dependency cycle"},
+		{"duplicate ingredient", func(form url.Values) { form["ingredient-name"] = []string{"bread", "bread"} }, `duplicate ingredient "bread"`},
+		{"duplicate task", func(form url.Values) { form["task-name"] = []string{"slice", "slice", "spread"} }, `duplicate task "slice"`},
+		{"bad name", func(form url.Values) { form["ingredient-name"] = []string{"Bread Slices", "butter"} }, `ingredient name "Bread Slices"`},
+		{"missing name", func(form url.Values) { form["name"] = []string{""} }, "recipe is missing a name"},
+		{"taken name", func(form url.Values) { form["name"] = []string{"pulled-pork"} }, `a recipe named "pulled-pork" already exists`},
+		{"bad group", func(form url.Values) { form["ingredient-group"] = []string{"Spreads", "Spreads"} }, `substitution group name "Spreads"`},
+		{"group of one", func(form url.Values) { form["ingredient-group"] = []string{"", "spread"} }, `substitution group "spread" has only one ingredient`},
+		{"cook time", func(form url.Values) { form["method-cook-time"] = []string{"soon"} }, `cook time "soon"`},
+		{"method named like a task", func(form url.Values) { form["method-name"] = []string{"slice"} }, `cooking method "slice" has the same name as a task`},
+		{"quantity without an amount", func(form url.Values) { form["ingredient-quantity"] = []string{"some", ""} }, `quantity "some" must start with an amount`},
+		{"quantity in an unknown unit", func(form url.Values) { form["ingredient-quantity"] = []string{"2 loaves", ""} }, `unknown unit "loaves"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := toastForm()
+			tt.change(form)
+
+			errs := admin.ParseForm(form).Check()
+
+			found := slices.ContainsFunc(errs, func(err error) bool {
+				return strings.Contains(err.Error(), tt.want)
+			})
+			if !found {
+				t.Logf("want '%s', got %v", tt.want, errs)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestBuildKeepsTranslations(t *testing.T) {
+	d := admin.NewDraft(recipes.PulledPork)
+	d.Recipe.Tasks = []recipes.Task{{Name: "place", Description: "Place the pork", Dependencies: []string{}}}
+
+	if errs := d.Check(); len(errs) > 0 {
+		t.Fatalf("want no errors, got %v", errs)
+	}
+
+	r := d.Build()
+
+	es := r.Translations["es"]
+	if es.Title != recipes.PulledPork.Translations["es"].Title {
+		t.Logf("want '%s', got '%s'", recipes.PulledPork.Translations["es"].Title, es.Title)
+		t.Fail()
+	}
+
+	if _, ok := es.Labels["place"]; !ok {
+		t.Log("want the label for a kept task")
+		t.Fail()
+	}
+
+	if _, ok := es.Labels["pour"]; ok {
+		t.Log("want no label for a removed task")
+		t.Fail()
+	}
+
+	err := r.Validate()
+	if err != nil {
+		t.Log(err.Error())
+		t.Fail()
+	}
+}
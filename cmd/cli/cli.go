@@ -0,0 +1,404 @@
+// Package cli implements the recipes subcommands, which work on the same
+// registry and validation as the server: the built-in recipes plus those in
+// --recipe-dir.
+package cli
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/graph"
+	"cooking-with-datastar/cmd/i18n"
+	"cooking-with-datastar/cmd/images"
+	"cooking-with-datastar/cmd/jsonld"
+	"cooking-with-datastar/cmd/recipes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `usage: recipes <command> [flags] [args]
+
+commands:
+  list                  list every recipe
+  show <recipe>         print a recipe file
+  validate <dir>        check the *.json recipe files in a directory
+  import <file|url>     convert a schema.org Recipe to a recipe file
+  export [recipe...]    print a list of recipes as schema.org JSON-LD
+  graph <recipe>        print the task dependency graph as DOT or Mermaid
+
+Run recipes <command> -h for a command's flags.`
+
+// errUsage is returned once the usage has been printed.
+var errUsage = errors.New("usage")
+
+// Env is what a command reads from and writes to.
+type Env struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Getenv func(string) string
+}
+
+var commands = map[string]func(e Env, args []string) error{
+	"list":     list,
+	"show":     show,
+	"validate": validate,
+	"import":   importRecipe,
+	"export":   export,
+	"graph":    drawGraph,
+}
+
+// Run runs `recipes <args>` and returns the exit code: 0 on success, 1 when
+// the command fails and 2 for bad usage.
+func Run(e Env, args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprintln(e.Stderr, usage)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(e.Stderr, "recipes: unknown command %q\n\n%s\n", args[0], usage)
+		return 2
+	}
+
+	err := cmd(e, args[1:])
+	switch {
+	case err == nil:
+		return 0
+
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		return 2
+
+	default:
+		fmt.Fprintf(e.Stderr, "recipes %s: %s\n", args[0], err.Error())
+		return 1
+	}
+}
+
+func newFlagSet(e Env, name string, params string) *flag.FlagSet {
+	fs := flag.NewFlagSet("recipes "+name, flag.ContinueOnError)
+	fs.SetOutput(e.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.Stderr, "usage: recipes %s [flags] %s\n", name, params)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// parse parses a command's flags and checks it was given n arguments, or any
+// number for -1.
+func parse(fs *flag.FlagSet, args []string, n int) error {
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if n >= 0 && fs.NArg() != n {
+		fs.Usage()
+		return errUsage
+	}
+
+	return nil
+}
+
+// registryFlag adds --recipe-dir, which defaults to COOKING_RECIPE_DIR like
+// the server's setting.
+func registryFlag(e Env, fs *flag.FlagSet) *string {
+	return fs.String("recipe-dir", e.Getenv("COOKING_RECIPE_DIR"), "A directory of *.json recipes to load alongside the built-in ones (COOKING_RECIPE_DIR)")
+}
+
+func loadRegistry(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	return recipes.LoadDir(dir)
+}
+
+func list(e Env, args []string) error {
+	fs := newFlagSet(e, "list", "")
+	dir := registryFlag(e, fs)
+	locale := fs.String("locale", "en", "The language of the titles")
+
+	err := parse(fs, args, 0)
+	if err != nil {
+		return err
+	}
+
+	err = loadRegistry(*dir)
+	if err != nil {
+		return err
+	}
+
+	ctx := i18n.WithLocale(context.Background(), *locale)
+
+	w := tabwriter.NewWriter(e.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tTITLE\tINGREDIENTS\tTASKS\tCOOK TIME")
+	for _, r := range recipes.ListRecipes() {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%d\t%s\n", r.Name, r.Version, i18n.Title(ctx, r), len(r.Ingredients), len(r.Tasks), r.CookingMethod.CookTime)
+	}
+
+	return w.Flush()
+}
+
+func show(e Env, args []string) error {
+	fs := newFlagSet(e, "show", "<recipe>")
+	dir := registryFlag(e, fs)
+	version := fs.Int("version", 0, "An earlier version of the recipe, rather than the latest")
+
+	err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	err = loadRegistry(*dir)
+	if err != nil {
+		return err
+	}
+
+	r, err := recipes.ParseRecipe(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+
+	if *version != 0 {
+		r, err = recipes.ParseRecipeVersion(r.Name, *version)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeJSON(e.Stdout, r)
+}
+
+// validate checks each recipe file on its own, so that every problem is
+// reported rather than the first that LoadDir would stop at.
+func validate(e Env, args []string) error {
+	fs := newFlagSet(e, "validate", "<dir>")
+
+	err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(fs.Arg(0), "*.json"))
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return fmt.Errorf("%s: no *.json recipe files", fs.Arg(0))
+	}
+
+	failed := 0
+	for _, p := range paths {
+		r, err := recipes.ReadRecipeFile(p)
+		if err == nil && r.Name == "" {
+			err = errors.New("recipe is missing a name")
+		}
+		if err == nil {
+			err = r.Validate()
+		}
+
+		if err != nil {
+			failed++
+			fmt.Fprintf(e.Stdout, "FAIL %s\n", p)
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Fprintf(e.Stdout, "     %s\n", line)
+			}
+			continue
+		}
+
+		fmt.Fprintf(e.Stdout, "ok   %s\n", p)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d recipes are not valid", failed, len(paths))
+	}
+
+	return nil
+}
+
+// importRecipe converts a schema.org Recipe from a saved page, a JSON-LD
+// file or a URL into a recipe file, which it prints or, with --write, saves to
+// --recipe-dir. The recipe is checked against the registry first, as an
+// import on the server is, so that it cannot clash with an existing one.
+func importRecipe(e Env, args []string) error {
+	fs := newFlagSet(e, "import", "<file|url>")
+	dir := registryFlag(e, fs)
+	write := fs.Bool("write", false, "Save the recipe to --recipe-dir instead of printing it")
+
+	err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	if *write && *dir == "" {
+		return errors.New("--write needs --recipe-dir")
+	}
+
+	err = loadRegistry(*dir)
+	if err != nil {
+		return err
+	}
+
+	src := fs.Arg(0)
+
+	var body io.ReadCloser
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		client := http.Client{Timeout: 30 * time.Second}
+
+		resp, err := client.Get(src)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("%s: %s", src, resp.Status)
+		}
+
+		body = resp.Body
+	} else {
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+
+		body = f
+	}
+	defer body.Close()
+
+	r, err := jsonld.Import(io.LimitReader(body, jsonld.MaxImportSize))
+	if err != nil {
+		return err
+	}
+
+	// A saved recipe keeps its picture where the server keeps uploads, as an
+	// import on the server does.
+	if *write && r.ImageSrc != "" {
+		store, err := images.NewStore(filepath.Join(*dir, "images"))
+		if err != nil {
+			return err
+		}
+
+		src, err := store.Fetch(context.Background(), r.ImageSrc)
+		if err != nil {
+			fmt.Fprintf(e.Stderr, "cannot fetch the picture, using the default one: %s\n", err.Error())
+		}
+
+		r.ImageSrc = src
+	}
+
+	r, err = recipes.RegisterNew(r)
+	if err != nil {
+		return err
+	}
+
+	if !*write {
+		return writeJSON(e.Stdout, r)
+	}
+
+	path, err := recipes.WriteRecipeFile(*dir, r)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(e.Stdout, path)
+
+	return nil
+}
+
+func export(e Env, args []string) error {
+	fs := newFlagSet(e, "export", "[recipe...]")
+	dir := registryFlag(e, fs)
+	base := fs.String("base-url", "http://localhost:8080", "The server the recipe and image URLs point at")
+	locale := fs.String("locale", "en", "The language to export in")
+
+	err := parse(fs, args, -1)
+	if err != nil {
+		return err
+	}
+
+	err = loadRegistry(*dir)
+	if err != nil {
+		return err
+	}
+
+	list := recipes.ListRecipes()
+	if fs.NArg() > 0 {
+		list = []recipes.Recipe{}
+		for _, name := range fs.Args() {
+			r, err := recipes.ParseRecipe(name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			list = append(list, r)
+		}
+	}
+
+	ctx := i18n.WithLocale(context.Background(), *locale)
+
+	exported := []jsonld.Recipe{}
+	for _, r := range list {
+		exported = append(exported, jsonld.Export(ctx, i18n.Localize(ctx, r), *base))
+	}
+
+	// Always a list, however many recipes were asked for, so that scripts
+	// can rely on the shape.
+	return writeJSON(e.Stdout, exported)
+}
+
+func drawGraph(e Env, args []string) error {
+	fs := newFlagSet(e, "graph", "<recipe>")
+	dir := registryFlag(e, fs)
+	format := fs.String("format", "dot", "dot or mermaid")
+
+	err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	draw, ok := map[string]func(recipes.Recipe) string{
+		"dot":     graph.DOT,
+		"mermaid": graph.Mermaid,
+	}[*format]
+	if !ok {
+		fmt.Fprintf(e.Stderr, "unknown format %q\n", *format)
+		fs.Usage()
+		return errUsage
+	}
+
+	err = loadRegistry(*dir)
+	if err != nil {
+		return err
+	}
+
+	r, err := recipes.ParseRecipe(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+
+	_, err = io.WriteString(e.Stdout, draw(r))
+
+	return err
+}
+
+func writeJSON(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(data))
+
+	return err
+}
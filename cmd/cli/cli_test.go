@@ -0,0 +1,90 @@
+package cli_test
+
+import (
+	"bytes"
+	"cooking-with-datastar/cmd/cli"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	env := cli.Env{Stdout: &stdout, Stderr: &stderr, Getenv: func(string) string { return "" }}
+
+	code := cli.Run(env, args)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+	}{
+		{"list", []string{"list"}, 0, "pulled-pork             1        Pulled pork"},
+		{"list from a directory", []string{"list", "--recipe-dir", "testdata/valid"}, 0, "toast"},
+		{"show", []string{"show", "pulled-pork"}, 0, `"name": "pulled-pork"`},
+		{"show an unknown recipe", []string{"show", "gazpacho"}, 1, ""},
+		{"validate", []string{"validate", "testdata/valid"}, 0, "ok   testdata/valid/toast.json"},
+		{"validate a broken recipe", []string{"validate", "testdata/invalid"}, 1, "FAIL testdata/invalid/circular.json"},
+		{"validate badly named recipes", []string{"validate", "testdata/invalid"}, 1, "FAIL testdata/invalid/bad-names.json"},
+		{"import", []string{"import", "../jsonld/testdata/overnight-oats.json"}, 0, `"name": "overnight-oats"`},
+		{"import a taken name", []string{"import", "testdata/pulled-pork.json"}, 1, ""},
+		{"import and write without a directory", []string{"import", "--write", "../jsonld/testdata/overnight-oats.json"}, 1, ""},
+		{"export", []string{"export", "--locale", "es", "pulled-pork"}, 0, "[\n\t{\n\t\t\"@context\""},
+		{"export in a language", []string{"export", "--locale", "es", "pulled-pork"}, 0, `"name": "Cerdo deshebrado"`},
+		{"export everything", []string{"export"}, 0, `"name": "Chocolate chip cookies"`},
+		{"graph", []string{"graph", "pulled-pork"}, 0, "t2 -> cook;"},
+		{"mermaid graph", []string{"graph", "--format", "mermaid", "pulled-pork"}, 0, "t2 --> cook"},
+		{"unknown graph format", []string{"graph", "--format", "png", "pulled-pork"}, 2, ""},
+		{"missing argument", []string{"show"}, 2, ""},
+		{"unknown command", []string{"cook"}, 2, ""},
+		{"no command", []string{}, 2, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(tt.args...)
+
+			if code != tt.code {
+				t.Logf("want exit code %d, got %d: %s", tt.code, code, stderr)
+				t.Fail()
+			}
+
+			if !strings.Contains(stdout, tt.stdout) {
+				t.Logf("want '%s' in '%s'", tt.stdout, stdout)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestImportWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	code, stdout, stderr := run("import", "--recipe-dir", dir, "--write", "../jsonld/testdata/banana-bread.html")
+	if code != 0 {
+		t.Fatalf("want exit code 0, got %d: %s", code, stderr)
+	}
+
+	want := filepath.Join(dir, "banana-bread.json")
+	if strings.TrimSpace(stdout) != want {
+		t.Logf("want '%s', got '%s'", want, stdout)
+		t.Fail()
+	}
+
+	_, err := os.Stat(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _, _ = run("import", "--recipe-dir", dir, "--write", "../jsonld/testdata/banana-bread.html")
+	if code != 1 {
+		t.Logf("want the second import to clash, got exit code %d", code)
+		t.Fail()
+	}
+}
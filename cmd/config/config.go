@@ -0,0 +1,368 @@
+package config
+
+import (
+	"bytes"
+	"cooking-with-datastar/cmd/internal"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const envPrefix = "COOKING_"
+
+const (
+	DevCertFile = "tls/dev-cert.pem"
+	DevKeyFile  = "tls/dev-key.pem"
+)
+
+type Config struct {
+	Addr    string
+	TLSCert string
+	TLSKey  string
+	// TLSSelfSigned generates a certificate at TLSCert and TLSKey on first
+	// run, which is enough for trying the app from a phone on the LAN.
+	TLSSelfSigned bool
+	CookieSecret  string
+	CookieMaxAge  time.Duration
+	RecipeDir     string
+	// AdminPassword protects the recipe editor and imports. They are
+	// disabled when it is empty.
+	AdminPassword string
+	LogLevel      string
+	LogFormat     string
+	// TimeScale multiplies every recipe's cook time, e.g. 60 turns the demo
+	// recipes' seconds into minutes.
+	TimeScale float64
+	// Assets picks where Pico and Datastar are loaded from: the vendored
+	// copies in the binary, the CDN, or auto (the default) to prefer the
+	// vendored copies and fall back to the CDN.
+	Assets string
+}
+
+func Default() Config {
+	return Config{
+		Addr:         ":8080",
+		CookieMaxAge: 24 * time.Hour,
+		LogLevel:     "debug",
+		LogFormat:    "json",
+		TimeScale:    1,
+		Assets:       internal.AssetsAuto,
+	}
+}
+
+type setting struct {
+	name  string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+// flagValue collects a flag as a string so that it is parsed by the same
+// setter as the file and environment values. Boolean flags can be given
+// without a value, e.g. --tls-self-signed.
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	return f.value
+}
+
+func (f *flagValue) Set(v string) error {
+	f.value = v
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// settings lists every option once. The flag is the name, the environment
+// variable is the name in upper snake case with the COOKING_ prefix and the
+// config file key is the name in camel case, e.g. "tls-cert", COOKING_TLS_CERT
+// and "tlsCert".
+var settings = []setting{
+	{"addr", "The address to listen on", func(c *Config, v string) error {
+		c.Addr = v
+		return nil
+	}},
+	{"port", "A port to listen on, shorthand for --addr :<port>", func(c *Config, v string) error {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+
+		c.Addr = fmt.Sprintf(":%d", port)
+		return nil
+	}},
+	{"tls-cert", "A TLS certificate file", func(c *Config, v string) error {
+		c.TLSCert = v
+		return nil
+	}},
+	{"tls-key", "A TLS private key file", func(c *Config, v string) error {
+		c.TLSKey = v
+		return nil
+	}},
+	{"tls-self-signed", "Generate a self-signed certificate for development if tls-cert and tls-key do not exist", func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+
+		c.TLSSelfSigned = b
+		return nil
+	}},
+	{"cookie-secret", "A secret used to sign cookies, at least 32 bytes", func(c *Config, v string) error {
+		c.CookieSecret = v
+		return nil
+	}},
+	{"cookie-max-age", "How long cooking progress is kept, e.g. 24h", func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+
+		c.CookieMaxAge = d
+		return nil
+	}},
+	{"recipe-dir", "A directory of *.json recipes to load alongside the built-in ones", func(c *Config, v string) error {
+		c.RecipeDir = v
+		return nil
+	}},
+	{"admin-password", "A password for the recipe editor and imports, asked for with HTTP basic auth", func(c *Config, v string) error {
+		c.AdminPassword = v
+		return nil
+	}},
+	{"log-level", "The minimum log level: debug, info, warn or error", func(c *Config, v string) error {
+		c.LogLevel = v
+		return nil
+	}},
+	{"log-format", "The log format: json or text", func(c *Config, v string) error {
+		c.LogFormat = v
+		return nil
+	}},
+	{"time-scale", "A multiplier applied to every recipe's cook time", func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+
+		c.TimeScale = f
+		return nil
+	}},
+	{"assets", "Where frontend assets are loaded from: " + strings.Join(internal.AssetModes, ", "), func(c *Config, v string) error {
+		c.Assets = v
+		return nil
+	}},
+}
+
+var boolSettings = []string{"tls-self-signed"}
+
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Load builds the config from, in increasing order of precedence, the
+// defaults, a JSON config file, COOKING_* environment variables and command
+// line flags. The config file is named by --config or COOKING_CONFIG.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	fs := flag.NewFlagSet("cooking-with-datastar", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	configPath := fs.String("config", "", "A JSON config file ("+envPrefix+"CONFIG)")
+
+	defaults := Default()
+	values := map[string]*flagValue{}
+	for _, s := range settings {
+		values[s.name] = &flagValue{isBool: slices.Contains(boolSettings, s.name)}
+		fs.Var(values[s.name], s.name, s.usage+" ("+envName(s.name)+")")
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(os.Stderr)
+			fmt.Fprintf(os.Stderr, "Defaults: addr %s, cookie-max-age %s, log-level %s, log-format %s, time-scale %v, assets %s\n\n",
+				defaults.Addr, defaults.CookieMaxAge, defaults.LogLevel, defaults.LogFormat, defaults.TimeScale, defaults.Assets)
+			fs.PrintDefaults()
+		}
+
+		return Config{}, err
+	}
+
+	visited := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		visited[f.Name] = true
+	})
+
+	c := defaults
+
+	path := internal.Ternary(visited["config"], *configPath, getenv(envPrefix+"CONFIG"))
+	if path != "" {
+		err := c.applyFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, s := range settings {
+		v := getenv(envName(s.name))
+		if v == "" {
+			continue
+		}
+
+		err := s.set(&c, v)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: %s: %w", envName(s.name), err)
+		}
+	}
+
+	for _, s := range settings {
+		if !visited[s.name] {
+			continue
+		}
+
+		err := s.set(&c, values[s.name].value)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: --%s: %w", s.name, err)
+		}
+	}
+
+	if c.TLSSelfSigned {
+		c.TLSCert = internal.Ternary(c.TLSCert == "", DevCertFile, c.TLSCert)
+		c.TLSKey = internal.Ternary(c.TLSKey == "", DevKeyFile, c.TLSKey)
+	}
+
+	err = c.Validate()
+	if err != nil {
+		return Config{}, err
+	}
+
+	return c, nil
+}
+
+func (c *Config) applyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	// Numbers are kept as written, since a float64 would print a port or a
+	// large timeout in exponent form.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var file map[string]any
+	err = dec.Decode(&file)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	known := map[string]setting{}
+	for _, s := range settings {
+		known[internal.ToCamelCase(s.name)] = s
+	}
+
+	for key, v := range file {
+		s, ok := known[key]
+		if !ok {
+			return fmt.Errorf("config: %s: unknown key %q", path, key)
+		}
+
+		var value string
+		switch v := v.(type) {
+		case string:
+			value = v
+
+		case json.Number:
+			value = v.String()
+
+		case bool:
+			value = strconv.FormatBool(v)
+
+		default:
+			return fmt.Errorf("config: %s: %s must be a string, number or boolean", path, key)
+		}
+
+		err := s.set(c, value)
+		if err != nil {
+			return fmt.Errorf("config: %s: %s: %w", path, key, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate reports every problem with the config at once so that they can all
+// be fixed before the next start.
+func (c Config) Validate() error {
+	errs := []error{}
+
+	if c.Addr == "" {
+		errs = append(errs, errors.New("addr must not be empty"))
+	}
+
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		errs = append(errs, errors.New("tls-cert and tls-key must be set together"))
+	}
+
+	for _, f := range []string{c.TLSCert, c.TLSKey} {
+		if f == "" || c.TLSSelfSigned {
+			continue
+		}
+
+		_, err := os.Stat(f)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.CookieSecret != "" && len(c.CookieSecret) < 32 {
+		errs = append(errs, fmt.Errorf("cookie-secret must be at least 32 bytes, got %d", len(c.CookieSecret)))
+	}
+
+	if c.CookieMaxAge < time.Second {
+		errs = append(errs, fmt.Errorf("cookie-max-age must be at least 1s, got %s", c.CookieMaxAge))
+	}
+
+	if c.RecipeDir != "" {
+		info, err := os.Stat(c.RecipeDir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recipe-dir: %w", err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("recipe-dir: %s is not a directory", c.RecipeDir))
+		}
+	}
+
+	var l slog.Level
+	err := l.UnmarshalText([]byte(c.LogLevel))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("log-level %q is not one of debug, info, warn or error", c.LogLevel))
+	}
+
+	if c.LogFormat != "json" && c.LogFormat != "text" {
+		errs = append(errs, fmt.Errorf("log-format %q is not one of json or text", c.LogFormat))
+	}
+
+	if c.TimeScale <= 0 {
+		errs = append(errs, fmt.Errorf("time-scale must be greater than 0, got %v", c.TimeScale))
+	}
+
+	if !slices.Contains(internal.AssetModes, c.Assets) {
+		errs = append(errs, fmt.Errorf("assets %q is not one of: %s", c.Assets, strings.Join(internal.AssetModes, ", ")))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package config_test
+
+import (
+	"cooking-with-datastar/cmd/config"
+	"cooking-with-datastar/cmd/internal"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	err := os.WriteFile(path, []byte(`{"addr": ":7000", "logLevel": "warn", "cookieMaxAge": "1h", "timeScale": 2}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{
+		"COOKING_CONFIG":    path,
+		"COOKING_LOG_LEVEL": "error",
+		"COOKING_ADDR":      ":7001",
+	}
+
+	c, err := config.Load([]string{"--addr", ":7002"}, func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		result   any
+		expected any
+	}{
+		{"flag beats env", c.Addr, ":7002"},
+		{"env beats file", c.LogLevel, "error"},
+		{"file beats default", c.CookieMaxAge, time.Hour},
+		{"file number", c.TimeScale, 2.0},
+		{"default", c.LogFormat, "json"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.result != tc.expected {
+				t.Logf("want '%v', got '%v'", tc.expected, tc.result)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestLoadFileNumbers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	err := os.WriteFile(path, []byte(`{"port": 1000000, "cookieSecret": "0123456789abcdef0123456789abcdef", "tlsSelfSigned": true}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := config.Load([]string{"--config", path}, func(string) string { return "" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Addr != ":1000000" || !c.TLSSelfSigned {
+		t.Logf("want ':1000000' and a self-signed certificate, got '%s' and %v", c.Addr, c.TLSSelfSigned)
+		t.Fail()
+	}
+}
+
+func TestLoadFileRejectsObjects(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	err := os.WriteFile(path, []byte(`{"addr": {"port": 8080}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = config.Load([]string{"--config", path}, func(string) string { return "" })
+	if err == nil || !strings.Contains(err.Error(), "addr must be a string, number or boolean") {
+		t.Logf("want an error for an object value, got '%v'", err)
+		t.Fail()
+	}
+}
+
+func TestLoadPort(t *testing.T) {
+	c, err := config.Load([]string{"--port", "9000"}, func(string) string { return "" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Addr != ":9000" {
+		t.Logf("want ':9000', got '%s'", c.Addr)
+		t.Fail()
+	}
+}
+
+func TestLoadSelfSigned(t *testing.T) {
+	c, err := config.Load([]string{"--tls-self-signed"}, func(string) string { return "" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.TLSSelfSigned || c.TLSCert != config.DevCertFile || c.TLSKey != config.DevKeyFile {
+		t.Logf("want self-signed dev certificate paths, got %+v", c)
+		t.Fail()
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"tls pair", []string{"--tls-cert", "cert.pem"}, "tls-cert and tls-key must be set together"},
+		{"short secret", []string{"--cookie-secret", "hunter2"}, "cookie-secret must be at least 32 bytes"},
+		{"max age", []string{"--cookie-max-age", "0s"}, "cookie-max-age must be at least 1s"},
+		{"log format", []string{"--log-format", "xml"}, `log-format "xml"`},
+		{"time scale", []string{"--time-scale", "-1"}, "time-scale must be greater than 0"},
+		{"recipe dir", []string{"--recipe-dir", "/does/not/exist"}, "recipe-dir"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := config.Load(tc.args, func(string) string { return "" })
+
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Logf("want error containing '%s', got '%v'", tc.expected, err)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestDefaultAssets(t *testing.T) {
+	// The static files as they are committed, which may not include the
+	// vendored assets.
+	sf, err := internal.NewStaticFiles(os.DirFS(".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = internal.UseStaticFiles(sf, config.Default().Assets)
+	if err != nil {
+		t.Logf("want the default config to start, got '%s'", err)
+		t.Fail()
+	}
+}
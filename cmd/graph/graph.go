@@ -0,0 +1,89 @@
+// Package graph draws a recipe's prep tasks as the dependency graph they form,
+// ending in the cooking method, which waits for every task.
+package graph
+
+import (
+	"cooking-with-datastar/cmd/recipes"
+	"fmt"
+	"strings"
+)
+
+// DOT writes the graph in Graphviz's language, for `dot -Tsvg`.
+func DOT(r recipes.Recipe) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %q {\n", r.String())
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box, style=rounded];\n")
+
+	for i, t := range r.ListPrepTasks() {
+		fmt.Fprintf(&b, "\tt%d [label=%q, tooltip=%q];\n", i, t.Name, t.Description)
+	}
+
+	method := r.GetCookingMethod()
+	fmt.Fprintf(&b, "\tcook [label=%q, tooltip=%q, shape=doubleoctagon];\n", method.Name, method.Description)
+
+	for _, e := range edges(r) {
+		fmt.Fprintf(&b, "\t%s -> %s;\n", e.from, e.to)
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// Mermaid writes the graph as a Mermaid flowchart, which GitHub and many
+// editors render from a ```mermaid block.
+func Mermaid(r recipes.Recipe) string {
+	var b strings.Builder
+
+	b.WriteString("flowchart LR\n")
+
+	for i, t := range r.ListPrepTasks() {
+		fmt.Fprintf(&b, "\tt%d[%q]\n", i, t.Name)
+	}
+
+	fmt.Fprintf(&b, "\tcook{{%q}}\n", r.GetCookingMethod().Name)
+
+	for _, e := range edges(r) {
+		fmt.Fprintf(&b, "\t%s --> %s\n", e.from, e.to)
+	}
+
+	return b.String()
+}
+
+type edge struct {
+	from string
+	to   string
+}
+
+// edges runs from each dependency to the task that needs it, and from every
+// task nothing else needs to the cooking method. Nodes are named t<index> for
+// tasks and cook for the method, since a task may share the method's name and
+// a hyphenated name is not a valid id in either language.
+func edges(r recipes.Recipe) []edge {
+	tasks := r.ListPrepTasks()
+
+	ids := map[string]string{}
+	for i, t := range tasks {
+		ids[t.Name] = fmt.Sprintf("t%d", i)
+	}
+
+	list := []edge{}
+	needed := map[string]bool{}
+
+	for _, t := range tasks {
+		for _, d := range t.Dependencies {
+			list = append(list, edge{ids[d], ids[t.Name]})
+			needed[d] = true
+		}
+	}
+
+	for _, t := range tasks {
+		if !needed[t.Name] {
+			list = append(list, edge{ids[t.Name], "cook"})
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,106 @@
+package graph_test
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/graph"
+	"cooking-with-datastar/cmd/recipes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// toast names a task after its cooking method, which must stay two nodes.
+var toast = recipes.Recipe{
+	Name:        "toast",
+	Ingredients: []recipes.Ingredient{{Name: "bread", Description: "2 slices of bread"}},
+	Tasks: []recipes.Task{
+		{Name: "slice", Description: "Slice the bread", Dependencies: []string{}},
+		{Name: "heat-the-grill", Description: "Heat the grill", Dependencies: []string{}},
+		{Name: "toast", Description: "Toast the slices", Dependencies: []string{"slice", "heat-the-grill"}},
+	},
+	CookingMethod: recipes.CookingMethod{Name: "toast", Description: "Serve while warm", CookTime: 90 * time.Second},
+}
+
+func TestDOT(t *testing.T) {
+	got := graph.DOT(toast)
+
+	for _, want := range []string{
+		`digraph "toast" {`,
+		`t1 [label="heat-the-grill", tooltip="Heat the grill"];`,
+		`cook [label="toast", tooltip="Serve while warm", shape=doubleoctagon];`,
+		"t0 -> t2;",
+		"t1 -> t2;",
+		"t2 -> cook;",
+	} {
+		if !strings.Contains(got, want) {
+			t.Logf("want '%s' in\n%s", want, got)
+			t.Fail()
+		}
+	}
+
+	if strings.Contains(got, "t0 -> cook") {
+		t.Logf("want no edge to the cooking method from a task that another needs, got\n%s", got)
+		t.Fail()
+	}
+}
+
+func TestMermaid(t *testing.T) {
+	expected := `flowchart LR
+	t0["slice"]
+	t1["heat-the-grill"]
+	t2["toast"]
+	cook{{"toast"}}
+	t0 --> t2
+	t1 --> t2
+	t2 --> cook
+`
+
+	got := graph.Mermaid(toast)
+	if got != expected {
+		t.Logf("want '%s', got '%s'", expected, got)
+		t.Fail()
+	}
+}
+
+func TestState(t *testing.T) {
+	tests := []struct {
+		name     string
+		finished map[string]bool
+		expected []string
+	}{
+		{"nothing finished", map[string]bool{}, []string{graph.Available, graph.Available, graph.Blocked}},
+		{"one dependency finished", map[string]bool{"slice": true}, []string{graph.Finished, graph.Available, graph.Blocked}},
+		{"all dependencies finished", map[string]bool{"slice": true, "heat-the-grill": true}, []string{graph.Finished, graph.Finished, graph.Available}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, task := range toast.Tasks {
+				got := graph.State(task, tt.finished)
+				if got != tt.expected[i] {
+					t.Logf("%s: want '%s', got '%s'", task.Name, tt.expected[i], got)
+					t.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestSVG(t *testing.T) {
+	got := graph.SVG(context.Background(), toast, map[string]bool{"slice": true, "heat-the-grill": true})
+
+	for _, want := range []string{
+		`<title id="task-graph-title">2 of 3 tasks done</title>`,
+		// A task in the second column, and the cooking method centred in the third.
+		`<g class="available"><title>Toast: Ready to start</title><rect x="216" y="8"`,
+		`<g class="blocked"><title>Toast: Waiting</title><rect x="424" y="36"`,
+		// The finished grill task's edge to the toast task.
+		`<path class="finished" d="M 168 84 C 192 84, 192 28, 216 28"`,
+		`<text x="88" y="84">Heat the grill</text>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Logf("want '%s' in\n%s", want, got)
+			t.Fail()
+		}
+	}
+}
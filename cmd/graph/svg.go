@@ -0,0 +1,180 @@
+package graph
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/i18n"
+	"cooking-with-datastar/cmd/recipes"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// The states a node is drawn in.
+const (
+	Finished  = "finished"
+	Available = "available"
+	Blocked   = "blocked"
+)
+
+// Sizes in SVG user units.
+const (
+	nodeWidth  = 160
+	nodeHeight = 40
+	gapX       = 48
+	gapY       = 16
+	padding    = 8
+	legendGap  = 28
+	// maxLabel is how many characters fit on a node at the font size used.
+	maxLabel = 20
+)
+
+// style is scoped to the graph, since the SVG is inlined in the page.
+const style = `#task-graph-svg text { font: 13px sans-serif; dominant-baseline: central; text-anchor: middle; }
+#task-graph-svg .finished rect { fill: #2e7d32; stroke: #1b5e20; }
+#task-graph-svg .finished text { fill: #fff; }
+#task-graph-svg .available rect { fill: #1565c0; stroke: #0d47a1; }
+#task-graph-svg .available text { fill: #fff; }
+#task-graph-svg .blocked rect { fill: #eceff1; stroke: #78909c; stroke-dasharray: 4 3; }
+#task-graph-svg .blocked text { fill: #37474f; }
+#task-graph-svg path { fill: none; stroke: #78909c; stroke-width: 1.5; }
+#task-graph-svg path.finished { stroke: #2e7d32; }
+#task-graph-svg marker path { fill: #78909c; stroke: none; }
+#task-graph-svg .legend text { text-anchor: start; fill: currentColor; }`
+
+// State is finished for a finished task, available for one whose
+// dependencies are all finished and blocked otherwise.
+func State(t recipes.Task, finished map[string]bool) string {
+	if finished[t.Name] {
+		return Finished
+	}
+
+	for _, d := range t.Dependencies {
+		if !finished[d] {
+			return Blocked
+		}
+	}
+
+	return Available
+}
+
+type node struct {
+	name  string
+	state string
+	x, y  int
+}
+
+// SVG draws the graph with each task coloured by its state in finished, in
+// columns by how many tasks come before it, and labelled in the request's
+// locale. The cooking method is available once every task is finished.
+func SVG(ctx context.Context, r recipes.Recipe, finished map[string]bool) string {
+	tasks := r.ListPrepTasks()
+
+	// Recipes are validated when registered, so sorting cannot fail on one
+	// that is being cooked.
+	sorted, err := recipes.SortTasks(tasks)
+	if err != nil {
+		sorted = tasks
+	}
+
+	column := map[string]int{}
+	columns := 0
+	for _, t := range sorted {
+		for _, d := range t.Dependencies {
+			column[t.Name] = max(column[t.Name], column[d]+1)
+		}
+		columns = max(columns, column[t.Name]+1)
+	}
+
+	rows := make([]int, columns)
+	nodes := map[string]*node{}
+	done := 0
+
+	for i, t := range tasks {
+		c := column[t.Name]
+		nodes[fmt.Sprintf("t%d", i)] = &node{
+			name:  t.Name,
+			state: State(t, finished),
+			x:     padding + c*(nodeWidth+gapX),
+			y:     padding + rows[c]*(nodeHeight+gapY),
+		}
+		rows[c]++
+
+		if finished[t.Name] {
+			done++
+		}
+	}
+
+	height := nodeHeight
+	for _, n := range rows {
+		height = max(height, n*(nodeHeight+gapY)-gapY)
+	}
+
+	method := r.GetCookingMethod()
+	nodes["cook"] = &node{
+		name:  method.Name,
+		state: methodState(done == len(tasks)),
+		x:     padding + columns*(nodeWidth+gapX),
+		y:     padding + (height-nodeHeight)/2,
+	}
+
+	width := padding*2 + (columns+1)*(nodeWidth+gapX) - gapX
+	legendY := padding*2 + height + legendGap/2
+	total := legendY + legendGap/2
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `<svg id="task-graph-svg" role="img" aria-labelledby="task-graph-title" viewBox="0 0 %d %d" width="100%%" style="max-width: %dpx;" xmlns="http://www.w3.org/2000/svg">`, width, total, width)
+	fmt.Fprintf(&b, `<title id="task-graph-title">%s</title>`, html.EscapeString(i18n.T(ctx, "prep.graph-summary", done, len(tasks))))
+	fmt.Fprintf(&b, `<style>%s</style>`, style)
+	b.WriteString(`<defs><marker id="task-graph-arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z"/></marker></defs>`)
+
+	for _, e := range edges(r) {
+		from, to := nodes[e.from], nodes[e.to]
+		x1, y1 := from.x+nodeWidth, from.y+nodeHeight/2
+		x2, y2 := to.x, to.y+nodeHeight/2
+		mid := (x1 + x2) / 2
+
+		fmt.Fprintf(&b, `<path class="%s" d="M %d %d C %d %d, %d %d, %d %d" marker-end="url(#task-graph-arrow)"/>`, from.state, x1, y1, mid, y1, mid, y2, x2, y2)
+	}
+
+	for i := range tasks {
+		writeNode(ctx, &b, r, nodes[fmt.Sprintf("t%d", i)], "4")
+	}
+	writeNode(ctx, &b, r, nodes["cook"], "20")
+
+	b.WriteString(`<g class="legend">`)
+	for i, state := range []string{Finished, Available, Blocked} {
+		x := padding + i*(nodeWidth-20)
+		fmt.Fprintf(&b, `<g class="%s"><rect x="%d" y="%d" width="14" height="14" rx="3"/><text x="%d" y="%d">%s</text></g>`,
+			state, x, legendY-7, x+20, legendY, html.EscapeString(i18n.T(ctx, "prep.graph-"+state)))
+	}
+	b.WriteString(`</g></svg>`)
+
+	return b.String()
+}
+
+// methodState is available once every task is finished.
+func methodState(ready bool) string {
+	if ready {
+		return Available
+	}
+
+	return Blocked
+}
+
+func writeNode(ctx context.Context, b *strings.Builder, r recipes.Recipe, n *node, radius string) {
+	label := i18n.Label(ctx, r, n.name)
+
+	fmt.Fprintf(b, `<g class="%s"><title>%s</title>`, n.state, html.EscapeString(label+": "+i18n.T(ctx, "prep.graph-"+n.state)))
+	fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" rx="%s"/>`, n.x, n.y, nodeWidth, nodeHeight, radius)
+	fmt.Fprintf(b, `<text x="%d" y="%d">%s</text></g>`, n.x+nodeWidth/2, n.y+nodeHeight/2, html.EscapeString(truncate(label, maxLabel)))
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,259 @@
+// Package i18n holds the message catalogs for the UI and picks a locale for
+// each request.
+package i18n
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/internal"
+	"cooking-with-datastar/cmd/recipes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const Default = "en"
+
+//go:embed locales/*.json
+var files embed.FS
+
+// catalogs maps a locale to its messages, keyed by message ID. Messages are
+// fmt format strings.
+var catalogs = map[string]map[string]string{}
+
+// Locales lists the supported locales, default first.
+var Locales = []string{}
+
+func init() {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, e := range entries {
+		data, err := files.ReadFile("locales/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+
+		messages := map[string]string{}
+		err = json.Unmarshal(data, &messages)
+		if err != nil {
+			panic(fmt.Errorf("%s: %w", e.Name(), err))
+		}
+
+		locale := strings.TrimSuffix(e.Name(), path.Ext(e.Name()))
+		catalogs[locale] = messages
+		Locales = append(Locales, locale)
+	}
+
+	slices.SortFunc(Locales, func(a, b string) int {
+		switch {
+		case a == Default:
+			return -1
+		case b == Default:
+			return 1
+		default:
+			return strings.Compare(a, b)
+		}
+	})
+}
+
+// Translate looks a message up in the locale's catalog, falling back to the
+// default locale and then to the ID itself.
+func Translate(locale string, id string, args ...any) string {
+	msg, ok := catalogs[locale][id]
+	if !ok {
+		msg, ok = catalogs[Default][id]
+	}
+
+	if !ok {
+		return id
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+
+	return fmt.Sprintf(msg, args...)
+}
+
+// T translates a message into the request's locale.
+func T(ctx context.Context, id string, args ...any) string {
+	return Translate(Locale(ctx), id, args...)
+}
+
+type localeKey struct{}
+
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// Locale returns the locale chosen by Negotiate, or the default outside of a
+// request.
+func Locale(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		return locale
+	}
+
+	return Default
+}
+
+// Negotiate picks the locale saved with the language picker, or failing that
+// the best match for the Accept-Language header.
+func Negotiate(opts internal.CookieOptions, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := ""
+
+		prefs, err := internal.NewSessionStorage(r, opts).GetPreferences()
+		if err == nil && slices.Contains(Locales, prefs.Locale) {
+			locale = prefs.Locale
+		} else {
+			locale = Match(r.Header.Get("Accept-Language"))
+		}
+
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", locale)
+
+		next.ServeHTTP(w, r.WithContext(WithLocale(r.Context(), locale)))
+	})
+}
+
+// Match returns the supported locale with the highest weight in an
+// Accept-Language header such as "es-MX,es;q=0.9,en;q=0.8". Region
+// subtags are ignored.
+func Match(acceptLanguage string) string {
+	best := Default
+	bestWeight := 0.0
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			w, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+
+			weight = w
+		}
+
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if weight > bestWeight && slices.Contains(Locales, base) {
+			best = base
+			bestWeight = weight
+		}
+	}
+
+	return best
+}
+
+// Name is the locale's name in its own language, for the language picker.
+func Name(locale string) string {
+	return Translate(locale, "language.name")
+}
+
+var number = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// FormatNumbers rewrites the numbers in a text, such as the quantities in an
+// ingredient list, with the locale's decimal and group separators.
+func FormatNumbers(locale string, text string) string {
+	decimal := Translate(locale, "number.decimal")
+	group := Translate(locale, "number.group")
+
+	return number.ReplaceAllStringFunc(text, func(n string) string {
+		whole, fraction, hasFraction := strings.Cut(n, ".")
+
+		// Leave years, temperatures and the like alone.
+		if len(whole) > 4 || (len(whole) == 4 && !hasFraction) {
+			return n
+		}
+
+		if len(whole) == 4 {
+			whole = whole[:1] + group + whole[1:]
+		}
+
+		if !hasFraction {
+			return whole
+		}
+
+		return whole + decimal + fraction
+	})
+}
+
+// Localize translates a recipe's descriptions into the request's locale and
+// formats the ingredient quantities for it.
+func Localize(ctx context.Context, r recipes.Recipe) recipes.Recipe {
+	locale := Locale(ctx)
+
+	r = r.Translate(locale)
+
+	r.Ingredients = slices.Clone(r.Ingredients)
+	for i := range r.Ingredients {
+		r.Ingredients[i].Description = FormatNumbers(locale, r.Ingredients[i].Description)
+	}
+
+	return r
+}
+
+// Title is the recipe's title in the request's locale.
+func Title(ctx context.Context, r recipes.Recipe) string {
+	if t, ok := r.Title(Locale(ctx)); ok {
+		return t
+	}
+
+	return internal.ToStartCase(r.String())
+}
+
+// Label is the button label for an ingredient, task or cooking method in the
+// request's locale.
+func Label(ctx context.Context, r recipes.Recipe, name string) string {
+	if l, ok := r.Label(Locale(ctx), name); ok {
+		return l
+	}
+
+	return internal.ToStartCase(name)
+}
+
+// DescribeGroup describes the ingredients of a substitution group as
+// alternatives, and marks an optional one.
+func DescribeGroup(ctx context.Context, g recipes.IngredientGroup) string {
+	descriptions := []string{}
+	for _, i := range g.Ingredients {
+		descriptions = append(descriptions, i.Description)
+	}
+
+	text := strings.Join(descriptions, " "+T(ctx, "gather.or")+" ")
+	if g.Optional() {
+		text += " (" + T(ctx, "gather.optional") + ")"
+	}
+
+	return text
+}
+
+// DescribeTimeRemaining rounds the time left up to a few coarse steps, so a
+// screen reader announcing it hears a change every minute, not every second.
+func DescribeTimeRemaining(ctx context.Context, seconds int) string {
+	switch {
+	case seconds <= 0:
+		return T(ctx, "cook.finished.title")
+
+	case seconds <= 10:
+		return T(ctx, "cook.seconds-left", 10)
+
+	case seconds <= 30:
+		return T(ctx, "cook.seconds-left", 30)
+
+	case seconds <= 60:
+		return T(ctx, "cook.minute-left")
+
+	default:
+		return T(ctx, "cook.minutes-left", (seconds+59)/60)
+	}
+}
@@ -0,0 +1,151 @@
+package i18n_test
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/i18n"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"empty", "", "en"},
+		{"unsupported", "fr-FR,fr;q=0.9", "en"},
+		{"region", "es-MX", "es"},
+		{"weights", "fr;q=0.9,en;q=0.5,es;q=0.8", "es"},
+		{"first wins a tie", "en,es", "en"},
+		{"bad weight", "es;q=x,en;q=0.1", "en"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := i18n.Match(tc.input)
+
+			if result != tc.expected {
+				t.Logf("want '%s', got '%s'", tc.expected, result)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name     string
+		locale   string
+		id       string
+		args     []any
+		expected string
+	}{
+		{"english", "en", "step.position", []any{1, 3}, "Step 1 of 3"},
+		{"spanish", "es", "step.position", []any{1, 3}, "Paso 1 de 3"},
+		{"unknown locale", "fr", "step.position", []any{2, 3}, "Step 2 of 3"},
+		{"unknown message", "es", "no.such.message", nil, "no.such.message"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := i18n.Translate(tc.locale, tc.id, tc.args...)
+
+			if result != tc.expected {
+				t.Logf("want '%s', got '%s'", tc.expected, result)
+				t.Fail()
+			}
+		})
+	}
+}
+
+// Every catalog should have every message the default one has, so nothing
+// silently falls back to English.
+func TestCatalogsAreComplete(t *testing.T) {
+	ids := func(locale string) []string {
+		data, err := os.ReadFile(filepath.Join("locales", locale+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		messages := map[string]string{}
+		err = json.Unmarshal(data, &messages)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return slices.Sorted(maps.Keys(messages))
+	}
+
+	expected := ids(i18n.Default)
+
+	for _, l := range i18n.Locales {
+		t.Run(l, func(t *testing.T) {
+			result := ids(l)
+
+			for _, id := range expected {
+				if !slices.Contains(result, id) {
+					t.Logf("missing '%s'", id)
+					t.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestFormatNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		locale   string
+		input    string
+		expected string
+	}{
+		{"english", "en", "1.5 cups mozzarella cheese", "1.5 cups mozzarella cheese"},
+		{"spanish", "es", "1.5 tazas de queso mozzarella", "1,5 tazas de queso mozzarella"},
+		{"whole numbers", "es", "Hornea de 20 a 30 minutos", "Hornea de 20 a 30 minutos"},
+		{"grouping", "en", "1250.5 grams", "1,250.5 grams"},
+		{"years", "es", "Since 1999", "Since 1999"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := i18n.FormatNumbers(tc.locale, tc.input)
+
+			if result != tc.expected {
+				t.Logf("want '%s', got '%s'", tc.expected, result)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestDescribeTimeRemaining(t *testing.T) {
+	tt := []struct {
+		name     string
+		input    int
+		expected string
+	}{
+		{"negative", -1, "Time's up!"},
+		{"min", 0, "Time's up!"},
+		{"one second", 1, "10 seconds left"},
+		{"eleven seconds", 11, "30 seconds left"},
+		{"one minute", 60, "1 minute left"},
+		{"one minute one second", 61, "2 minutes left"},
+		{"ten minutes", 600, "10 minutes left"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result := i18n.DescribeTimeRemaining(context.Background(), tc.input)
+
+			if result != tc.expected {
+				t.Logf("want '%s', got '%s'", tc.expected, result)
+				t.Fail()
+			}
+		})
+	}
+}
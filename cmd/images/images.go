@@ -0,0 +1,256 @@
+// Package images validates, resizes and stores uploaded recipe pictures.
+// Files are named after a hash of their content, so their URLs never change
+// meaning and can be cached forever.
+package images
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// URLPrefix is where stored images are served from.
+	URLPrefix = "/images/"
+
+	// MaxUploadSize bounds the encoded upload.
+	MaxUploadSize = 8 << 20
+
+	// MaxPixels bounds the decoded upload, since a small file can declare a
+	// huge image.
+	MaxPixels = 40_000_000
+
+	// Width is the largest stored image, which is twice the width the cook
+	// step shows it at. Thumbnails are ThumbnailWidth wide.
+	Width          = 640
+	ThumbnailWidth = 160
+)
+
+var (
+	ErrFormat   = errors.New("images: only PNG, JPEG and GIF images can be uploaded")
+	ErrTooLarge = fmt.Errorf("images: images must be at most %d megapixels", MaxPixels/1_000_000)
+)
+
+var formats = []string{"png", "jpeg", "gif"}
+
+var namePattern = regexp.MustCompile(`^[0-9a-f]{16}(-thumb)?\.(png|jpg)$`)
+
+type Store struct {
+	dir string
+}
+
+// NewStore keeps images in dir, creating it if needed.
+func NewStore(dir string) (*Store, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{dir}, nil
+}
+
+// Save checks and resizes an upload and stores it with a thumbnail. It
+// returns the image's URL, to be used as a recipe's ImageSrc.
+func (s *Store) Save(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxUploadSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > MaxUploadSize {
+		return "", fmt.Errorf("images: uploads must be at most %d MB", MaxUploadSize>>20)
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || !slices.Contains(formats, format) {
+		return "", ErrFormat
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return "", ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("images: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:8])
+
+	// Photos are smaller as JPEG, but that would lose transparency.
+	ext := ".jpg"
+	if !opaque(img) {
+		ext = ".png"
+	}
+
+	for _, v := range []struct {
+		suffix string
+		width  int
+	}{{"", Width}, {"-thumb", ThumbnailWidth}} {
+		err := s.write(name+v.suffix+ext, Resize(img, v.width))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return URLPrefix + name + ext, nil
+}
+
+// Fetch downloads an image, such as an imported recipe's, and saves it as an
+// upload is, so that it is served from here rather than from the page it was
+// imported from.
+func (s *Store) Fetch(ctx context.Context, src string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return "", err
+	}
+
+	client := http.Client{Timeout: 30 * time.Second}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("images: %s: %s", src, resp.Status)
+	}
+
+	return s.Save(resp.Body)
+}
+
+func (s *Store) write(name string, img image.Image) error {
+	var buf bytes.Buffer
+
+	var err error
+	if strings.HasSuffix(name, ".png") {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return err
+	}
+
+	// Write then rename, so that a request never sees half a file.
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(buf.Bytes())
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, name))
+}
+
+// Open returns a stored image by its URL.
+func (s *Store) Open(src string) (io.ReadSeekCloser, error) {
+	name, ok := strings.CutPrefix(src, URLPrefix)
+	if !ok || !namePattern.MatchString(name) {
+		return nil, os.ErrNotExist
+	}
+
+	return os.Open(filepath.Join(s.dir, name))
+}
+
+// ServeHTTP serves GET /images/{image}.
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f, err := s.Open(URLPrefix + r.PathValue("image"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(w, r, r.PathValue("image"), time.Time{}, f)
+}
+
+// Thumbnail is the URL of an image's thumbnail. Images that were not uploaded,
+// such as the built-in pixel art, are already small and are their own.
+func Thumbnail(src string) string {
+	name, ok := strings.CutPrefix(src, URLPrefix)
+	if !ok || !namePattern.MatchString(name) || strings.Contains(name, "-thumb") {
+		return src
+	}
+
+	ext := filepath.Ext(name)
+
+	return URLPrefix + strings.TrimSuffix(name, ext) + "-thumb" + ext
+}
+
+// Resize scales img down to width, keeping its aspect ratio, by averaging the
+// source pixels under each destination pixel. Narrower images are copied as
+// they are.
+func Resize(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= width {
+		dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+		return dst
+	}
+
+	height := max(1, b.Dy()*width/b.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := range height {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := max(y0+1, b.Min.Y+(y+1)*b.Dy()/height)
+
+		for x := range width {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := max(x0+1, b.Min.X+(x+1)*b.Dx()/width)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(bl / n), uint16(a / n)})
+		}
+	}
+
+	return dst
+}
+
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+
+	return false
+}
@@ -0,0 +1,251 @@
+package images_test
+
+import (
+	"bytes"
+	"context"
+	"cooking-with-datastar/cmd/images"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func checkerboard(w int, h int, opaque bool) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			c := color.NRGBA{255, 255, 255, 255}
+			if (x+y)%2 == 0 {
+				c = color.NRGBA{0, 0, 0, 255}
+			}
+			if !opaque && x == 0 {
+				c.A = 0
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name   string
+		w, h   int
+		width  int
+		expect image.Point
+	}{
+		{"landscape", 1280, 960, 640, image.Pt(640, 480)},
+		{"portrait", 300, 900, 160, image.Pt(160, 480)},
+		{"already small", 100, 50, 640, image.Pt(100, 50)},
+		{"sliver", 2000, 1, 160, image.Pt(160, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := images.Resize(checkerboard(tt.w, tt.h, true), tt.width).Bounds().Size()
+			if got != tt.expect {
+				t.Logf("want '%v', got '%v'", tt.expect, got)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestResizeAverages(t *testing.T) {
+	// Halving a checkerboard averages each black and white pair to grey.
+	got := images.Resize(checkerboard(4, 4, true), 2)
+
+	r, _, _, _ := got.At(0, 0).RGBA()
+	if r>>8 < 120 || r>>8 > 135 {
+		t.Logf("want grey, got %d", r>>8)
+		t.Fail()
+	}
+}
+
+func TestSave(t *testing.T) {
+	store, err := images.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		ext    string
+		width  int
+		decode func(*bytes.Reader) (image.Image, error)
+	}{
+		{"photo", encodePNG(t, checkerboard(1000, 500, true)), ".jpg", images.Width, func(r *bytes.Reader) (image.Image, error) { return jpeg.Decode(r) }},
+		{"transparent", encodePNG(t, checkerboard(200, 100, false)), ".png", 200, func(r *bytes.Reader) (image.Image, error) { return png.Decode(r) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := store.Save(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.HasPrefix(src, images.URLPrefix) || !strings.HasSuffix(src, tt.ext) {
+				t.Logf("want an %s image under %s, got '%s'", tt.ext, images.URLPrefix, src)
+				t.Fail()
+			}
+
+			for _, v := range []struct {
+				src   string
+				width int
+			}{{src, tt.width}, {images.Thumbnail(src), images.ThumbnailWidth}} {
+				rec := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, v.src, nil)
+				req.SetPathValue("image", strings.TrimPrefix(v.src, images.URLPrefix))
+				store.ServeHTTP(rec, req)
+
+				if rec.Code != http.StatusOK {
+					t.Fatalf("%s: want 200, got %d", v.src, rec.Code)
+				}
+
+				if !strings.Contains(rec.Header().Get("Cache-Control"), "immutable") {
+					t.Logf("want an immutable Cache-Control, got '%s'", rec.Header().Get("Cache-Control"))
+					t.Fail()
+				}
+
+				img, err := tt.decode(bytes.NewReader(rec.Body.Bytes()))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if img.Bounds().Dx() != v.width {
+					t.Logf("%s: want width %d, got %d", v.src, v.width, img.Bounds().Dx())
+					t.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	store, err := images.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := encodePNG(t, checkerboard(20, 10, true))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/soup.png" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	src, err := store.Fetch(context.Background(), server.URL+"/soup.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := store.Open(src)
+	if err != nil {
+		t.Fatalf("want the fetched image to be stored, got '%s'", err)
+	}
+	f.Close()
+
+	_, err = store.Fetch(context.Background(), server.URL+"/missing.png")
+	if err == nil {
+		t.Log("want an error for a missing image")
+		t.Fail()
+	}
+}
+
+func TestSaveRejects(t *testing.T) {
+	store, err := images.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A valid PNG header claiming to be far larger than its data.
+	huge := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	copy(huge[16:24], []byte{0, 0, 0x4e, 0x20, 0, 0, 0x4e, 0x20})
+	binary.BigEndian.PutUint32(huge[29:33], crc32.ChecksumIEEE(huge[12:29]))
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"not an image", []byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>"), images.ErrFormat},
+		{"too many pixels", huge, images.ErrTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := store.Save(bytes.NewReader(tt.data))
+			if !errors.Is(err, tt.want) {
+				t.Logf("want '%v', got '%v'", tt.want, err)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestServeRejectsOtherFiles(t *testing.T) {
+	store, err := images.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"../images_test.go", ".upload-123", "0123456789abcdef.gif"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/images/x", nil)
+		req.SetPathValue("image", name)
+		store.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Logf("%s: want 404, got %d", name, rec.Code)
+			t.Fail()
+		}
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"/images/0123456789abcdef.jpg", "/images/0123456789abcdef-thumb.jpg"},
+		{"/images/0123456789abcdef-thumb.png", "/images/0123456789abcdef-thumb.png"},
+		{"/static/hamburger_small.png", "/static/hamburger_small.png"},
+		{"https://example.com/soup.jpg", "https://example.com/soup.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := images.Thumbnail(tt.input)
+			if got != tt.expected {
+				t.Logf("want '%s', got '%s'", tt.expected, got)
+				t.Fail()
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+)
+
+// RequireAdmin asks for the admin password with HTTP basic auth, under any
+// user name. Without a password the handler is disabled rather than left
+// open, since it can change recipes for everyone.
+//
+// Browsers resend basic auth on cross-site requests too, so requests that
+// change something must also come from this site.
+func RequireAdmin(password string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if password == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, given, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="admin", charset="UTF-8"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		if !safeMethod(r.Method) && !SameOrigin(r) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// SameOrigin reports whether a request was made by a page on this site. It
+// trusts Sec-Fetch-Site when the browser sends it and falls back to Origin.
+// Requests with neither, such as from curl, are not from a browser and so
+// cannot be forged by another site.
+func SameOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return true
+
+	case "":
+
+	default:
+		return false
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return u.Host == r.Host
+}
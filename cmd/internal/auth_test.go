@@ -0,0 +1,72 @@
+package internal_test
+
+import (
+	"cooking-with-datastar/cmd/internal"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name     string
+		password string
+		method   string
+		auth     string
+		headers  map[string]string
+		status   int
+	}{
+		{name: "no password disables", password: "", method: http.MethodGet, status: http.StatusNotFound},
+		{name: "no password disables posts", password: "", method: http.MethodPost, status: http.StatusNotFound},
+		{name: "missing auth", password: "secret", method: http.MethodGet, status: http.StatusUnauthorized},
+		{name: "wrong password", password: "secret", method: http.MethodGet, auth: "wrong", status: http.StatusUnauthorized},
+		{name: "right password", password: "secret", method: http.MethodGet, auth: "secret", status: http.StatusOK},
+		{
+			name:     "same-origin post",
+			password: "secret", method: http.MethodPost, auth: "secret",
+			headers: map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://example.com"},
+			status:  http.StatusOK,
+		},
+		{
+			name:     "cross-site post",
+			password: "secret", method: http.MethodPost, auth: "secret",
+			headers: map[string]string{"Sec-Fetch-Site": "cross-site"},
+			status:  http.StatusForbidden,
+		},
+		{
+			name:     "cross-origin post without fetch metadata",
+			password: "secret", method: http.MethodPost, auth: "secret",
+			headers: map[string]string{"Origin": "https://evil.example"},
+			status:  http.StatusForbidden,
+		},
+		{
+			name:     "same-origin post without fetch metadata",
+			password: "secret", method: http.MethodPost, auth: "secret",
+			headers: map[string]string{"Origin": "http://example.com"},
+			status:  http.StatusOK,
+		},
+		{name: "post from a script", password: "secret", method: http.MethodPost, auth: "secret", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://example.com/admin/recipes/save", nil)
+			if tt.auth != "" {
+				r.SetBasicAuth("admin", tt.auth)
+			}
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			internal.RequireAdmin(tt.password, ok).ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Logf("want %d, got %d", tt.status, w.Code)
+				t.Fail()
+			}
+		})
+	}
+}
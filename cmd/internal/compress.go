@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"net/http"
+
+	"github.com/CAFxX/httpcompression"
+)
+
+// Compress gzips or brotli-compresses responses for clients that accept it.
+// SSE streams are excluded by content type and passed through untouched:
+// compressing them would buffer each countdown tick until enough data had
+// built up to be worth compressing. Images are already compressed.
+func Compress(next http.Handler) (http.Handler, error) {
+	adapter, err := httpcompression.DefaultAdapter(
+		httpcompression.ContentTypes([]string{
+			"text/event-stream",
+			"image/png",
+			"image/jpeg",
+			"image/gif",
+			"image/webp",
+		}, true),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return adapter(next), nil
+}
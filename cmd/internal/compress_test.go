@@ -0,0 +1,54 @@
+package internal_test
+
+import (
+	"cooking-with-datastar/cmd/internal"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	page := strings.Repeat("<p>Let&rsquo;s get cooking!</p>", 100)
+
+	handler, err := internal.Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cook" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("event: datastar-patch-elements\n\n"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(page))
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		accept   string
+		expected string
+	}{
+		{"html", "/", "text/html", "gzip"},
+		{"sse", "/cook", "text/event-stream", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Accept", tc.accept)
+			req.Header.Set("Accept-Encoding", "gzip")
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			result := w.Header().Get("Content-Encoding")
+			if result != tc.expected {
+				t.Logf("want '%s', got '%s'", tc.expected, result)
+				t.Fail()
+			}
+		})
+	}
+}
@@ -1,49 +1,194 @@
 package internal
 
 import (
+	"cooking-with-datastar/cmd/pantry"
 	"cooking-with-datastar/cmd/recipes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
+type CookieOptions struct {
+	MaxAge time.Duration
+	// Secret signs every cookie value with HMAC-SHA256 when it is set, so a
+	// cookie edited by hand is treated as missing.
+	Secret []byte
+	// TimeScale multiplies the recipe's cook time when cooking starts.
+	TimeScale float64
+}
+
+func DefaultCookieOptions() CookieOptions {
+	return CookieOptions{
+		MaxAge:    24 * time.Hour,
+		TimeScale: 1,
+	}
+}
+
+func (o CookieOptions) scale(d time.Duration) time.Duration {
+	if o.TimeScale <= 0 {
+		return d
+	}
+
+	return time.Duration(float64(d) * o.TimeScale).Round(time.Second)
+}
+
 type CookieStorage struct {
 	recipe recipes.Recipe
 	req    *http.Request
+	opts   CookieOptions
+	// stocked ingredients start out gathered.
+	stocked map[string]bool
 }
 
-func NewCookieStorage(recipe recipes.Recipe, req *http.Request) CookieStorage {
+func NewCookieStorage(recipe recipes.Recipe, req *http.Request, opts CookieOptions) CookieStorage {
 	return CookieStorage{
-		recipe,
-		req,
+		recipe: recipe,
+		req:    req,
+		opts:   opts,
+	}
+}
+
+// WithPantry starts the ingredients that are in stock out gathered, for a
+// recipe whose ingredients have not been gathered yet.
+func (cs CookieStorage) WithPantry(stocked map[string]bool) CookieStorage {
+	cs.stocked = stocked
+	return cs
+}
+
+// NewSessionStorage is for state that is not tied to a recipe, such as the
+// user's preferences.
+func NewSessionStorage(req *http.Request, opts CookieOptions) CookieStorage {
+	return CookieStorage{
+		req:  req,
+		opts: opts,
+	}
+}
+
+func (cs CookieStorage) newCookie(name string, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(cs.opts.MaxAge.Seconds()),
+		HttpOnly: true,                 // Do not allow JS to modify the cookie
+		Secure:   true,                 // Only use HTTPS (and localhost)
+		SameSite: http.SameSiteLaxMode, // Send cookie when navigating *to* our site
+	}
+}
+
+func (cs CookieStorage) signature(name string, value string) string {
+	mac := hmac.New(sha256.New, cs.opts.Secret)
+	mac.Write([]byte(name + "=" + value))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// readCookie returns the named request cookie with its signature checked and
+// removed. A cookie with a bad signature is reported as missing.
+func (cs CookieStorage) readCookie(name string) (*http.Cookie, error) {
+	cookie, err := cs.req.Cookie(name)
+	if err != nil {
+		return nil, err
+	}
+
+	value := cookie.Value
+
+	if len(cs.opts.Secret) > 0 {
+		i := strings.LastIndexByte(value, '.')
+		if i < 0 || !hmac.Equal([]byte(value[i+1:]), []byte(cs.signature(name, value[:i]))) {
+			DecodeErrors.Inc("signature")
+			return nil, http.ErrNoCookie
+		}
+
+		value = value[:i]
+	}
+
+	return cs.newCookie(name, value), nil
+}
+
+// Sign returns a copy of the cookie that is ready to send to the client.
+func (cs CookieStorage) Sign(cookie *http.Cookie) *http.Cookie {
+	signed := *cookie
+
+	if len(cs.opts.Secret) > 0 {
+		signed.Value = cookie.Value + "." + cs.signature(cookie.Name, cookie.Value)
 	}
+
+	return &signed
+}
+
+func (cs CookieStorage) SetCookie(w http.ResponseWriter, cookie *http.Cookie) {
+	http.SetCookie(w, cs.Sign(cookie))
+}
+
+// Started reports whether the recipe has a step cookie, i.e. whether this
+// session has already started cooking it.
+func (cs CookieStorage) Started() bool {
+	_, err := cs.readCookie(cs.recipe.String() + "-step")
+	return err == nil
+}
+
+// StartedCooking reports whether the recipe has a cooking method cookie,
+// which the cook stream sets when the countdown starts.
+func (cs CookieStorage) StartedCooking() bool {
+	_, err := cs.readCookie(cs.recipe.String() + "-cook")
+	return err == nil
 }
 
 func (cs CookieStorage) GetStepCookie() (*http.Cookie, error) {
-	recipeName := cs.recipe.String()
-	cookieName := recipeName + "-step"
+	cookieName := cs.recipe.String() + "-step"
 
-	cookie, err := cs.req.Cookie(cookieName)
+	cookie, err := cs.readCookie(cookieName)
 	if err != nil {
 		if !errors.Is(err, http.ErrNoCookie) {
 			return nil, err
 		}
 
-		cookie = &http.Cookie{
-			Name:     cookieName,
-			Value:    recipes.GetFirstStep().String(),
-			Path:     "/",
-			MaxAge:   int((24 * time.Hour).Seconds()),
-			HttpOnly: true,                 // Do not allow JS to modify the cookie
-			Secure:   true,                 // Only use HTTPS (and localhost)
-			SameSite: http.SameSiteLaxMode, // Send cookie when navigating *to* our site
+		cookie = cs.newCookie(cookieName, recipes.GetFirstStep().String())
+	}
+
+	return cookie, nil
+}
+
+// GetVersionCookie pins a session to the version of the recipe it started
+// with, so that saving a new version does not change the recipe mid-cook.
+func (cs CookieStorage) GetVersionCookie() (*http.Cookie, error) {
+	cookieName := cs.recipe.String() + "-version"
+
+	cookie, err := cs.readCookie(cookieName)
+	if err != nil {
+		if !errors.Is(err, http.ErrNoCookie) {
+			return nil, err
 		}
+
+		cookie = cs.newCookie(cookieName, strconv.Itoa(cs.recipe.Version))
 	}
 
-	cookie.Path = "/"
+	return cookie, nil
+}
+
+// GetCookCookie identifies one cook of the recipe, so that its end is only
+// acted on once however many tabs report it.
+func (cs CookieStorage) GetCookCookie() (*http.Cookie, error) {
+	cookieName := cs.recipe.String() + "-cook-id"
+
+	cookie, err := cs.readCookie(cookieName)
+	if err != nil {
+		if !errors.Is(err, http.ErrNoCookie) {
+			return nil, err
+		}
+
+		cookie = cs.newCookie(cookieName, pantry.NewID())
+	}
 
 	return cookie, nil
 }
@@ -56,10 +201,12 @@ func (cs CookieStorage) ToNextStep() (*http.Cookie, error) {
 
 	step, err := recipes.ParseRecipeStep(cookie.Value)
 	if err != nil {
+		DecodeErrors.Inc("step")
 		return nil, err
 	}
 
-	cookie.Path = "/"
+	StepTransitions.Inc(cs.recipe.String(), step.String(), step.GetNextStep().String())
+
 	cookie.Value = step.GetNextStep().String()
 
 	return cookie, nil
@@ -69,25 +216,15 @@ func (cs CookieStorage) GetTaskCookie(task recipes.Task) (*http.Cookie, error) {
 	recipeName := cs.recipe.String()
 	cookieName := recipeName + "-task-" + task.Name
 
-	cookie, err := cs.req.Cookie(cookieName)
+	cookie, err := cs.readCookie(cookieName)
 	if err != nil {
 		if !errors.Is(err, http.ErrNoCookie) {
 			return nil, err
 		}
 
-		cookie = &http.Cookie{
-			Name:     cookieName,
-			Value:    "false",
-			Path:     "/",
-			MaxAge:   int((24 * time.Hour).Seconds()),
-			HttpOnly: true,                 // Do not allow JS to modify the cookie
-			Secure:   true,                 // Only use HTTPS (and localhost)
-			SameSite: http.SameSiteLaxMode, // Send cookie when navigating *to* our site
-		}
+		cookie = cs.newCookie(cookieName, "false")
 	}
 
-	cookie.Path = "/"
-
 	return cookie, nil
 }
 
@@ -112,7 +249,10 @@ func (cs CookieStorage) FinishTask(task recipes.Task) (*http.Cookie, error) {
 		return nil, err
 	}
 
-	cookie.Path = "/"
+	if cookie.Value != "true" {
+		TaskCompletions.Inc(cs.recipe.String(), task.Name)
+	}
+
 	cookie.Value = "true"
 
 	return cookie, nil
@@ -137,7 +277,7 @@ func (cs CookieStorage) GetIngredientsCookie() (*http.Cookie, error) {
 	recipeName := cs.recipe.String()
 	cookieName := recipeName + "-ingredients"
 
-	cookie, err := cs.req.Cookie(cookieName)
+	cookie, err := cs.readCookie(cookieName)
 	if err != nil {
 		if !errors.Is(err, http.ErrNoCookie) {
 			return nil, err
@@ -146,7 +286,7 @@ func (cs CookieStorage) GetIngredientsCookie() (*http.Cookie, error) {
 		gathered := map[string]bool{}
 
 		for _, v := range cs.recipe.ListIngredients() {
-			gathered[v.Name] = false
+			gathered[v.Name] = cs.stocked[v.Name]
 		}
 
 		json, err := json.Marshal(gathered)
@@ -154,19 +294,9 @@ func (cs CookieStorage) GetIngredientsCookie() (*http.Cookie, error) {
 			return nil, err
 		}
 
-		cookie = &http.Cookie{
-			Name:     cookieName,
-			Value:    hex.EncodeToString(json),
-			Path:     "/",
-			MaxAge:   int((24 * time.Hour).Seconds()),
-			HttpOnly: true,                 // Do not allow JS to modify the cookie
-			Secure:   true,                 // Only use HTTPS (and localhost)
-			SameSite: http.SameSiteLaxMode, // Send cookie when navigating *to* our site
-		}
+		cookie = cs.newCookie(cookieName, hex.EncodeToString(json))
 	}
 
-	cookie.Path = "/"
-
 	return cookie, nil
 }
 
@@ -190,7 +320,6 @@ func (cs CookieStorage) GatherIngredients(form url.Values) (*http.Cookie, error)
 		return nil, err
 	}
 
-	cookie.Path = "/"
 	cookie.Value = hex.EncodeToString(data)
 
 	return cookie, nil
@@ -204,12 +333,14 @@ func (cs CookieStorage) GetGatheredIngredients() (map[string]bool, error) {
 
 	data, err := hex.DecodeString(cookie.Value)
 	if err != nil {
+		DecodeErrors.Inc("ingredients")
 		return nil, err
 	}
 
 	var gathered map[string]bool
 	err = json.Unmarshal(data, &gathered)
 	if err != nil {
+		DecodeErrors.Inc("ingredients")
 		return nil, err
 	}
 
@@ -219,47 +350,33 @@ func (cs CookieStorage) GetGatheredIngredients() (map[string]bool, error) {
 func (cs CookieStorage) FinishedGatheringIngredients(cookie *http.Cookie) (bool, error) {
 	data, err := hex.DecodeString(cookie.Value)
 	if err != nil {
+		DecodeErrors.Inc("ingredients")
 		return false, err
 	}
 
 	var gathered map[string]bool
 	err = json.Unmarshal(data, &gathered)
 	if err != nil {
+		DecodeErrors.Inc("ingredients")
 		return false, err
 	}
 
-	for _, b := range gathered {
-		if !b {
-			return false, nil
-		}
-	}
-
-	return true, nil
+	return cs.recipe.FinishedGathering(gathered), nil
 }
 
 func (cs CookieStorage) GetCookingMethodCookie() (*http.Cookie, error) {
 	recipeName := cs.recipe.String()
 	cookieName := recipeName + "-cook"
 
-	cookie, err := cs.req.Cookie(cookieName)
+	cookie, err := cs.readCookie(cookieName)
 	if err != nil {
 		if !errors.Is(err, http.ErrNoCookie) {
 			return nil, err
 		}
 
-		cookie = &http.Cookie{
-			Name:     cookieName,
-			Value:    cs.recipe.GetCookingMethod().CookTime.String(),
-			Path:     "/",
-			MaxAge:   int((24 * time.Hour).Seconds()),
-			HttpOnly: true,                 // Do not allow JS to modify the cookie
-			Secure:   true,                 // Only use HTTPS (and localhost)
-			SameSite: http.SameSiteLaxMode, // Send cookie when navigating *to* our site
-		}
+		cookie = cs.newCookie(cookieName, cs.opts.scale(cs.recipe.GetCookingMethod().CookTime).String())
 	}
 
-	cookie.Path = "/"
-
 	return cookie, nil
 }
 
@@ -271,6 +388,7 @@ func (cs CookieStorage) GetRemainingCookTime() (time.Duration, error) {
 
 	timeRemaining, err := time.ParseDuration(cookie.Value)
 	if err != nil {
+		DecodeErrors.Inc("cook")
 		return 1 * time.Hour, err
 	}
 
@@ -286,23 +404,264 @@ func (cs CookieStorage) FinishedCooking() (bool, error) {
 	return timeRemaining.Seconds() <= 0, nil
 }
 
-func (cs CookieStorage) DecrementCookingMethodCookie(amount time.Duration) (*http.Cookie, error) {
+// DecrementCookingMethodCookieTo lowers the remaining cook time to the given
+// amount. The remaining time never goes back up, so repeating a request for a
+// second that has already been counted down (e.g. after an SSE reconnect) is a
+// no-op rather than a second decrement.
+func (cs CookieStorage) DecrementCookingMethodCookieTo(remaining time.Duration) (*http.Cookie, error) {
 	cookie, err := cs.GetCookingMethodCookie()
 	if err != nil {
 		return nil, err
 	}
 
 	timeRemaining, err := time.ParseDuration(cookie.Value)
+	if err != nil {
+		DecodeErrors.Inc("cook")
+		return nil, err
+	}
+
+	if timeRemaining.Seconds() <= 0 || remaining >= timeRemaining {
+		return cookie, nil
+	}
+
+	if remaining <= 0 {
+		RecipeCompletions.Inc(cs.recipe.String())
+	}
+
+	cookie.Value = max(remaining, 0).String()
+
+	return cookie, nil
+}
+
+// PantryMaxAge is how long the pantry cookie is kept. The pantry outlives any
+// one cook, so it is not bound by CookieOptions.MaxAge.
+const PantryMaxAge = 400 * 24 * time.Hour
+
+// GetPantryCookie identifies the user's pantry, with a new ID for a user who
+// has none yet.
+func (cs CookieStorage) GetPantryCookie() (*http.Cookie, error) {
+	cookieName := "pantry"
+
+	cookie, err := cs.readCookie(cookieName)
+	if err != nil {
+		if !errors.Is(err, http.ErrNoCookie) {
+			return nil, err
+		}
+
+		cookie = cs.newCookie(cookieName, pantry.NewID())
+	}
+	cookie.MaxAge = int(PantryMaxAge.Seconds())
+
+	return cookie, nil
+}
+
+// ShoppingList is the recipes a shopping list was made for and the items
+// checked off it.
+type ShoppingList struct {
+	Recipes []string        `json:"recipes"`
+	Checked map[string]bool `json:"checked"`
+}
+
+func (cs CookieStorage) GetShoppingListCookie() (*http.Cookie, error) {
+	cookieName := "shopping-list"
+
+	cookie, err := cs.readCookie(cookieName)
+	if err != nil {
+		if !errors.Is(err, http.ErrNoCookie) {
+			return nil, err
+		}
+
+		data, err := json.Marshal(ShoppingList{Recipes: []string{}, Checked: map[string]bool{}})
+		if err != nil {
+			return nil, err
+		}
+
+		cookie = cs.newCookie(cookieName, hex.EncodeToString(data))
+	}
+
+	return cookie, nil
+}
+
+func (cs CookieStorage) GetShoppingList() (ShoppingList, error) {
+	cookie, err := cs.GetShoppingListCookie()
+	if err != nil {
+		return ShoppingList{}, err
+	}
+
+	data, err := hex.DecodeString(cookie.Value)
+	if err != nil {
+		DecodeErrors.Inc("shopping-list")
+		return ShoppingList{}, err
+	}
+
+	var list ShoppingList
+	err = json.Unmarshal(data, &list)
+	if err != nil {
+		DecodeErrors.Inc("shopping-list")
+		return ShoppingList{}, err
+	}
+
+	if list.Checked == nil {
+		list.Checked = map[string]bool{}
+	}
+
+	return list, nil
+}
+
+// SelectShoppingList starts a list for the recipes. Picking the same recipes
+// again keeps what has been checked off.
+func (cs CookieStorage) SelectShoppingList(recipeNames []string) (ShoppingList, *http.Cookie, error) {
+	list, err := cs.GetShoppingList()
+	if err != nil {
+		return ShoppingList{}, nil, err
+	}
+
+	if !slices.Equal(list.Recipes, recipeNames) {
+		list = ShoppingList{Recipes: recipeNames, Checked: map[string]bool{}}
+	}
+
+	cookie, err := cs.saveShoppingList(list)
+	if err != nil {
+		return ShoppingList{}, nil, err
+	}
+
+	return list, cookie, nil
+}
+
+// CheckShoppingList records the items checked in the form, which only
+// includes the checked boxes.
+func (cs CookieStorage) CheckShoppingList(items []string, form url.Values) (*http.Cookie, error) {
+	list, err := cs.GetShoppingList()
 	if err != nil {
 		return nil, err
 	}
 
-	if timeRemaining.Seconds() <= 0 {
-		return cookie, err
+	list.Checked = map[string]bool{}
+	for _, name := range items {
+		if form.Has(name) {
+			list.Checked[name] = true
+		}
 	}
 
-	cookie.Path = "/"
-	cookie.Value = (timeRemaining - amount).String()
+	return cs.saveShoppingList(list)
+}
+
+func (cs CookieStorage) saveShoppingList(list ShoppingList) (*http.Cookie, error) {
+	cookie, err := cs.GetShoppingListCookie()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+
+	cookie.Value = hex.EncodeToString(data)
+
+	return cookie, nil
+}
+
+type Preferences struct {
+	// Notify shows a browser notification when a cook countdown finishes.
+	Notify bool `json:"notify"`
+	// Sound plays an alarm, until it is acknowledged, when a cook countdown
+	// finishes.
+	Sound bool `json:"sound"`
+	// Narrate reads each step aloud as the cook gets to it.
+	Narrate bool `json:"narrate"`
+	// Locale is the one picked with the language picker. When it is empty
+	// the locale comes from the Accept-Language header.
+	Locale string `json:"locale,omitempty"`
+}
+
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Notify:  false,
+		Sound:   true,
+		Narrate: false,
+	}
+}
+
+func (cs CookieStorage) GetPreferencesCookie() (*http.Cookie, error) {
+	cookieName := "preferences"
+
+	cookie, err := cs.readCookie(cookieName)
+	if err != nil {
+		if !errors.Is(err, http.ErrNoCookie) {
+			return nil, err
+		}
+
+		data, err := json.Marshal(DefaultPreferences())
+		if err != nil {
+			return nil, err
+		}
+
+		cookie = cs.newCookie(cookieName, hex.EncodeToString(data))
+	}
+
+	return cookie, nil
+}
+
+func (cs CookieStorage) GetPreferences() (Preferences, error) {
+	cookie, err := cs.GetPreferencesCookie()
+	if err != nil {
+		return Preferences{}, err
+	}
+
+	data, err := hex.DecodeString(cookie.Value)
+	if err != nil {
+		DecodeErrors.Inc("preferences")
+		return Preferences{}, err
+	}
+
+	var prefs Preferences
+	err = json.Unmarshal(data, &prefs)
+	if err != nil {
+		DecodeErrors.Inc("preferences")
+		return Preferences{}, err
+	}
+
+	return prefs, nil
+}
+
+func (cs CookieStorage) SetPreferences(form url.Values) (*http.Cookie, error) {
+	prefs, err := cs.GetPreferences()
+	if err != nil {
+		return nil, err
+	}
+
+	// As with the ingredients, unchecked checkboxes are left out of the form.
+	prefs.Notify = form.Has("notify")
+	prefs.Sound = form.Has("sound")
+	prefs.Narrate = form.Has("narrate")
+
+	return cs.savePreferences(prefs)
+}
+
+func (cs CookieStorage) SetLocale(locale string) (*http.Cookie, error) {
+	prefs, err := cs.GetPreferences()
+	if err != nil {
+		return nil, err
+	}
+
+	prefs.Locale = locale
+
+	return cs.savePreferences(prefs)
+}
+
+func (cs CookieStorage) savePreferences(prefs Preferences) (*http.Cookie, error) {
+	cookie, err := cs.GetPreferencesCookie()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return nil, err
+	}
+
+	cookie.Value = hex.EncodeToString(data)
 
 	return cookie, nil
 }
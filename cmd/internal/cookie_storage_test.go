@@ -0,0 +1,256 @@
+package internal_test
+
+import (
+	"bytes"
+	"cooking-with-datastar/cmd/internal"
+	"cooking-with-datastar/cmd/recipes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedCookies(t *testing.T) {
+	opts := internal.DefaultCookieOptions()
+	opts.Secret = []byte("0123456789abcdef0123456789abcdef")
+
+	req := httptest.NewRequest(http.MethodGet, "/recipe/pulled-pork", nil)
+	cs := internal.NewCookieStorage(recipes.PulledPork, req, opts)
+
+	cookie, err := cs.ToNextStep()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := cs.Sign(cookie)
+	if signed.Value == cookie.Value {
+		t.Fatal("want the signed value to differ from the plain value")
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"signed", signed.Value, recipes.Prepare.String()},
+		{"tampered", "cook" + signed.Value[len("prepare"):], recipes.GetFirstStep().String()},
+		{"unsigned", "cook", recipes.GetFirstStep().String()},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/recipe/pulled-pork", nil)
+			req.AddCookie(&http.Cookie{Name: cookie.Name, Value: tc.value})
+
+			result, err := internal.NewCookieStorage(recipes.PulledPork, req, opts).GetStepCookie()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Value != tc.expected {
+				t.Logf("want '%s', got '%s'", tc.expected, result.Value)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestWithPantry(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/recipe/pulled-pork", nil)
+	cs := internal.NewCookieStorage(recipes.PulledPork, req, internal.DefaultCookieOptions()).WithPantry(map[string]bool{"ketchup": true})
+
+	gathered, err := cs.GetGatheredIngredients()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, i := range recipes.PulledPork.ListIngredients() {
+		if gathered[i.Name] != (i.Name == "ketchup") {
+			t.Logf("%s: want gathered %v, got %v", i.Name, i.Name == "ketchup", gathered[i.Name])
+			t.Fail()
+		}
+	}
+
+	// Once the ingredients cookie is set, the pantry no longer applies.
+	cookie, err := cs.GetIngredientsCookie()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/recipe/pulled-pork", nil)
+	req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+
+	gathered, err = internal.NewCookieStorage(recipes.PulledPork, req, internal.DefaultCookieOptions()).WithPantry(map[string]bool{"vinegar": true}).GetGatheredIngredients()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gathered["vinegar"] || !gathered["ketchup"] {
+		t.Logf("want the cookie's ingredients, got %v", gathered)
+		t.Fail()
+	}
+}
+
+func TestDecrementCookingMethodCookieTo(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   string
+		remaining time.Duration
+		expected  string
+	}{
+		{"counts down", "10s", 9 * time.Second, "9s"},
+		{"never goes up", "5s", 9 * time.Second, "5s"},
+		{"repeat is a no-op", "9s", 9 * time.Second, "9s"},
+		{"stops at zero", "1s", -1 * time.Second, "0s"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/cook/pulled-pork", nil)
+			req.AddCookie(&http.Cookie{Name: "pulled-pork-cook", Value: tc.current})
+
+			cs := internal.NewCookieStorage(recipes.PulledPork, req, internal.DefaultCookieOptions())
+
+			cookie, err := cs.DecrementCookingMethodCookieTo(tc.remaining)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if cookie.Value != tc.expected {
+				t.Logf("want '%s', got '%s'", tc.expected, cookie.Value)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestPreferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		form     url.Values
+		expected internal.Preferences
+	}{
+		{"none", url.Values{}, internal.Preferences{Notify: false, Sound: false}},
+		{"notify", url.Values{"notify": {"on"}}, internal.Preferences{Notify: true, Sound: false}},
+		{"both", url.Values{"notify": {"on"}, "sound": {"on"}}, internal.Preferences{Notify: true, Sound: true}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := internal.DefaultCookieOptions()
+
+			req := httptest.NewRequest(http.MethodPatch, "/preferences", nil)
+			cs := internal.NewSessionStorage(req, opts)
+
+			defaults, err := cs.GetPreferences()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if defaults != internal.DefaultPreferences() {
+				t.Logf("want '%v', got '%v'", internal.DefaultPreferences(), defaults)
+				t.Fail()
+			}
+
+			cookie, err := cs.SetPreferences(tc.form)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req = httptest.NewRequest(http.MethodGet, "/recipe/pulled-pork", nil)
+			req.AddCookie(cs.Sign(cookie))
+
+			result, err := internal.NewSessionStorage(req, opts).GetPreferences()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result != tc.expected {
+				t.Logf("want '%v', got '%v'", tc.expected, result)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestShoppingList(t *testing.T) {
+	opts := internal.DefaultCookieOptions()
+	req := httptest.NewRequest(http.MethodGet, "/shopping", nil)
+
+	_, cookie, err := internal.NewSessionStorage(req, opts).SelectShoppingList([]string{"pulled-pork"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/shopping", nil)
+	req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+
+	form := url.Values{"ketchup": {"on"}, "unknown": {"on"}}
+	cookie, err = internal.NewSessionStorage(req, opts).CheckShoppingList([]string{"ketchup", "vinegar"}, form)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		recipes []string
+		checked bool
+	}{
+		{name: "same recipes keep checks", recipes: []string{"pulled-pork"}, checked: true},
+		{name: "other recipes start over", recipes: []string{"pulled-pork", "buffalo-chicken-dip"}, checked: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/shopping", nil)
+			req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+
+			list, _, err := internal.NewSessionStorage(req, opts).SelectShoppingList(tt.recipes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if list.Checked["ketchup"] != tt.checked || list.Checked["vinegar"] || list.Checked["unknown"] {
+				t.Logf("want ketchup checked %v only, got %v", tt.checked, list.Checked)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestStarted(t *testing.T) {
+	opts := internal.DefaultCookieOptions()
+	recipe := recipes.Recipe{Name: "started-test"}
+
+	req := httptest.NewRequest(http.MethodGet, "/recipe/started-test", nil)
+	cs := internal.NewCookieStorage(recipe, req, opts)
+
+	if cs.Started() {
+		t.Log("want not started without a step cookie")
+		t.Fail()
+	}
+
+	cookie, err := cs.GetStepCookie()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reading the step is not starting the recipe; the handler that sets
+	// the cookie counts that.
+	var buf bytes.Buffer
+	internal.WriteMetrics(&buf)
+	if strings.Contains(buf.String(), `recipe="started-test"`) {
+		t.Log("want no recipe start recorded by reading the step")
+		t.Fail()
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/recipe/started-test", nil)
+	req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+
+	if !internal.NewCookieStorage(recipe, req, opts).Started() {
+		t.Log("want started with a step cookie")
+		t.Fail()
+	}
+}
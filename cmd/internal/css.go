@@ -2,10 +2,12 @@ package internal
 
 import "cooking-with-datastar/cmd/recipes"
 
+// GetBorderStyle marks the current step with a solid border and the others
+// with a dashed one, so the difference doesn't rely on colour alone.
 func GetBorderStyle(current recipes.Step, target recipes.Step) string {
 	if current == target {
 		return "border: .25rem solid var(--pico-primary); border-radius: 5px;"
 	}
 
-	return "border: .25rem solid var(--pico-color-grey-100); border-radius: 5px;"
+	return "border: .25rem dashed var(--pico-color-grey-100); border-radius: 5px;"
 }
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime/debug"
+)
+
+type ReadinessCheck struct {
+	Name  string
+	Check func() error
+}
+
+func HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("ok\n"))
+	})
+}
+
+// ReadyHandler runs every check on each request and responds with 503 if any
+// of them fail, so a load balancer only routes to an instance that can serve
+// recipes.
+func ReadyHandler(checks ...ReadinessCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusOK
+		results := map[string]string{}
+
+		for _, c := range checks {
+			err := c.Check()
+			if err != nil {
+				LoggerFromContext(r.Context()).Warn(
+					"Readiness check failed",
+					slog.String("check", c.Name),
+					slog.String("error", err.Error()),
+				)
+				status = http.StatusServiceUnavailable
+				results[c.Name] = err.Error()
+				continue
+			}
+
+			results[c.Name] = "ok"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{
+			"status": Ternary(status == http.StatusOK, "ok", "unavailable"),
+			"checks": results,
+		})
+	})
+}
+
+// WritableDir checks that files can be created in dir, which a full disk or
+// a read-only mount would prevent.
+func WritableDir(dir string) func() error {
+	return func() error {
+		f, err := os.CreateTemp(dir, ".readyz-*")
+		if err != nil {
+			return err
+		}
+
+		f.Close()
+
+		return os.Remove(f.Name())
+	}
+}
+
+type BuildInfo struct {
+	Path      string `json:"path"`
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified"`
+}
+
+func ReadBuildInfo() BuildInfo {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return BuildInfo{Version: "unknown"}
+	}
+
+	info := BuildInfo{
+		Path:      bi.Main.Path,
+		Version:   bi.Main.Version,
+		GoVersion: bi.GoVersion,
+	}
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+
+		case "vcs.time":
+			info.Time = s.Value
+
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+
+	return info
+}
+
+func VersionHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ReadBuildInfo())
+	})
+}
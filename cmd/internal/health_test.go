@@ -0,0 +1,47 @@
+package internal_test
+
+import (
+	"cooking-with-datastar/cmd/internal"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadyHandler(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name   string
+		dir    string
+		status int
+	}{
+		{"writable", dir, http.StatusOK},
+		{"missing", filepath.Join(dir, "missing"), http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := internal.ReadyHandler(internal.ReadinessCheck{Name: "store", Check: internal.WritableDir(tt.dir)})
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if w.Code != tt.status {
+				t.Logf("want %d, got %d: %s", tt.status, w.Code, w.Body)
+				t.Fail()
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 0 {
+		t.Logf("want the check to clean up after itself, got %d files", len(entries))
+		t.Fail()
+	}
+}
@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+type loggerKey struct{}
+
+func NewLogger(w io.Writer, level string, format string) (*slog.Logger, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(level))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: l}
+
+	switch format {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+
+	default:
+		return nil, fmt.Errorf("invalid log format %q, expected json or text", format)
+	}
+}
+
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext returns the request-scoped logger set by LogRequests,
+// falling back to the default logger outside of a request.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.Default()
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+
+	n, err := rr.ResponseWriter.Write(b)
+	rr.bytes += n
+
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// Flush is implemented directly as well as through Unwrap, since wrappers
+// such as the compression middleware only look for http.Flusher.
+func (rr *responseRecorder) Flush() {
+	http.NewResponseController(rr.ResponseWriter).Flush()
+}
+
+// LogRequests gives every request an ID, makes a logger carrying that ID
+// available through the request context and logs a line once the response
+// has been written.
+func LogRequests(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID, err := gonanoid.New()
+		if err != nil {
+			logger.Error("Cannot generate request ID", slog.String("error", err.Error()))
+		}
+
+		w.Header().Set("X-Request-ID", requestID)
+
+		requestLogger := logger.With(
+			slog.String("request_id", requestID),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		rr := &responseRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rr, r.WithContext(WithLogger(r.Context(), requestLogger)))
+
+		requestLogger.Info(
+			"Handled request",
+			slog.Int("status", Ternary(rr.status == 0, http.StatusOK, rr.status)),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int("bytes", rr.bytes),
+		)
+	})
+}
@@ -0,0 +1,93 @@
+package internal_test
+
+import (
+	"bytes"
+	"cooking-with-datastar/cmd/internal"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestLogRequests(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := internal.NewLogger(&buf, "info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := internal.LogRequests(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internal.LoggerFromContext(r.Context()).Info("Brewing")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recipe/pulled-pork", nil))
+
+	requestID := w.Header().Get("X-Request-ID")
+	if requestID == "" {
+		t.Log("want an X-Request-ID header")
+		t.Fail()
+	}
+
+	type line struct {
+		Msg       string `json:"msg"`
+		RequestID string `json:"request_id"`
+		Method    string `json:"method"`
+		Path      string `json:"path"`
+		Status    int    `json:"status"`
+		Bytes     int    `json:"bytes"`
+	}
+
+	lines := []line{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var l line
+		err := dec.Decode(&l)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines = append(lines, l)
+	}
+
+	// The handler's own line comes from the request-scoped logger, so it
+	// carries the same request fields as the line LogRequests writes.
+	expected := []line{
+		{"Brewing", requestID, http.MethodGet, "/recipe/pulled-pork", 0, 0},
+		{"Handled request", requestID, http.MethodGet, "/recipe/pulled-pork", http.StatusTeapot, 15},
+	}
+
+	if !slices.Equal(lines, expected) {
+		t.Logf("want %+v, got %+v", expected, lines)
+		t.Fail()
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  string
+		format string
+		valid  bool
+	}{
+		{"json debug", "debug", "json", true},
+		{"text warn", "WARN", "text", true},
+		{"bad level", "loud", "json", false},
+		{"bad format", "info", "xml", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := internal.NewLogger(&bytes.Buffer{}, tc.level, tc.format)
+
+			if (err == nil) != tc.valid {
+				t.Logf("want valid %v, got error '%v'", tc.valid, err)
+				t.Fail()
+			}
+		})
+	}
+}
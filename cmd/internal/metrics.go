@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The metrics below are written in the Prometheus text exposition format by
+// MetricsHandler. They are package level so that handlers and CookieStorage
+// can record them without having a registry threaded through.
+var (
+	RecipeStarts      = newCounter("cooking_recipe_starts_total", "Recipes started, by recipe.", "recipe")
+	RecipeCompletions = newCounter("cooking_recipe_completions_total", "Recipes cooked to completion, by recipe.", "recipe")
+	StepTransitions   = newCounter("cooking_step_transitions_total", "Moves from one recipe step to the next.", "recipe", "from", "to")
+	TaskCompletions   = newCounter("cooking_task_completions_total", "Prep tasks finished, by recipe and task.", "recipe", "task")
+	DecodeErrors      = newCounter("cooking_state_decode_errors_total", "Cookie or session state values that could not be decoded.", "state")
+	ActiveCookStreams = newGauge("cooking_active_cook_streams", "Open SSE cook countdown streams, by recipe.", "recipe")
+	RequestDuration   = newHistogram("cooking_http_request_duration_seconds", "Handler latency, by route.", []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}, "route")
+)
+
+var registry = []metric{
+	RecipeStarts,
+	RecipeCompletions,
+	StepTransitions,
+	TaskCompletions,
+	DecodeErrors,
+	ActiveCookStreams,
+	RequestDuration,
+}
+
+type metric interface {
+	write(w io.Writer)
+}
+
+// series holds one value per combination of label values.
+type series struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (s *series) key(labelValues []string) string {
+	if len(labelValues) != len(s.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", s.name, len(s.labels), len(labelValues)))
+	}
+
+	pairs := make([]string, len(s.labels))
+	for i, l := range s.labels {
+		pairs[i] = fmt.Sprintf("%s=%q", l, labelValues[i])
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func (s *series) add(v float64, labelValues []string) {
+	key := s.key(labelValues)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[key] += v
+}
+
+func (s *series) write(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", s.name, s.help, s.name, s.kind)
+
+	for _, key := range slices.Sorted(maps.Keys(s.values)) {
+		fmt.Fprintf(w, "%s%s %s\n", s.name, braces(key), formatFloat(s.values[key]))
+	}
+}
+
+type Counter struct {
+	*series
+}
+
+func newCounter(name string, help string, labels ...string) Counter {
+	return Counter{&series{name: name, help: help, kind: "counter", labels: labels, values: map[string]float64{}}}
+}
+
+func (c Counter) Inc(labelValues ...string) {
+	c.add(1, labelValues)
+}
+
+type Gauge struct {
+	*series
+}
+
+func newGauge(name string, help string, labels ...string) Gauge {
+	return Gauge{&series{name: name, help: help, kind: "gauge", labels: labels, values: map[string]float64{}}}
+}
+
+func (g Gauge) Inc(labelValues ...string) {
+	g.add(1, labelValues)
+}
+
+func (g Gauge) Dec(labelValues ...string) {
+	g.add(-1, labelValues)
+}
+
+type histogramValues struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+type Histogram struct {
+	*series
+	bounds     []float64
+	histograms map[string]*histogramValues
+}
+
+func newHistogram(name string, help string, bounds []float64, labels ...string) Histogram {
+	return Histogram{
+		&series{name: name, help: help, kind: "histogram", labels: labels},
+		bounds,
+		map[string]*histogramValues{},
+	}
+}
+
+func (h Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hv, ok := h.histograms[key]
+	if !ok {
+		hv = &histogramValues{buckets: make([]uint64, len(h.bounds))}
+		h.histograms[key] = hv
+	}
+
+	for i, b := range h.bounds {
+		if v <= b {
+			hv.buckets[i]++
+		}
+	}
+
+	hv.count++
+	hv.sum += v
+}
+
+func (h Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", h.name, h.help, h.name, h.kind)
+
+	for _, key := range slices.Sorted(maps.Keys(h.histograms)) {
+		hv := h.histograms[key]
+		prefix := Ternary(key == "", "", key+",")
+
+		for i, b := range h.bounds {
+			fmt.Fprintf(w, "%s_bucket{%sle=%q} %d\n", h.name, prefix, formatFloat(b), hv.buckets[i])
+		}
+
+		fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", h.name, prefix, hv.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, braces(key), formatFloat(hv.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, braces(key), hv.count)
+	}
+}
+
+func braces(key string) string {
+	return Ternary(key == "", "", "{"+key+"}")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func WriteMetrics(w io.Writer) {
+	for _, m := range registry {
+		m.write(w)
+	}
+}
+
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteMetrics(w)
+	})
+}
+
+// MeasureRequests only records handler latency, by route pattern. Recipe
+// starts and the other counters are recorded by the handlers, where the
+// cooking state changes. The mux sets the pattern on the request it is given
+// once it has found a match, so anything between this and the mux, such as
+// Compress, has to pass the same request on.
+func MeasureRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		next.ServeHTTP(w, r)
+
+		RequestDuration.Observe(time.Since(start).Seconds(), Ternary(r.Pattern == "", "unmatched", r.Pattern))
+	})
+}
@@ -0,0 +1,35 @@
+package internal_test
+
+import (
+	"bytes"
+	"cooking-with-datastar/cmd/internal"
+	"strings"
+	"testing"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	internal.TaskCompletions.Inc("metrics-test", "stir")
+	internal.TaskCompletions.Inc("metrics-test", "stir")
+	internal.RequestDuration.Observe(0.02, "GET /metrics-test")
+
+	var buf bytes.Buffer
+	internal.WriteMetrics(&buf)
+	out := buf.String()
+
+	expected := []string{
+		"# TYPE cooking_task_completions_total counter",
+		`cooking_task_completions_total{recipe="metrics-test",task="stir"} 2`,
+		"# TYPE cooking_http_request_duration_seconds histogram",
+		`cooking_http_request_duration_seconds_bucket{route="GET /metrics-test",le="0.01"} 0`,
+		`cooking_http_request_duration_seconds_bucket{route="GET /metrics-test",le="0.025"} 1`,
+		`cooking_http_request_duration_seconds_bucket{route="GET /metrics-test",le="+Inf"} 1`,
+		`cooking_http_request_duration_seconds_count{route="GET /metrics-test"} 1`,
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(out, e+"\n") {
+			t.Logf("want line '%s' in:\n%s", e, out)
+			t.Fail()
+		}
+	}
+}
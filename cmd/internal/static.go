@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	AssetsAuto     = "auto"
+	AssetsEmbedded = "embedded"
+	AssetsCDN      = "cdn"
+)
+
+var AssetModes = []string{AssetsAuto, AssetsEmbedded, AssetsCDN}
+
+type FrontendAsset struct {
+	Name string
+	CDN  string
+	// File is the vendored copy under static/, fetched by vendor.sh.
+	File string
+}
+
+//go:embed frontend_assets.txt
+var frontendAssets string
+
+// FrontendAssets are pinned to exact versions in frontend_assets.txt, which
+// vendor.sh also reads.
+var FrontendAssets = parseFrontendAssets(frontendAssets)
+
+// parseFrontendAssets reads one tab-separated name, file and CDN URL per
+// line, skipping blank lines and # comments.
+func parseFrontendAssets(text string) []FrontendAsset {
+	assets := []FrontendAsset{}
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			panic(fmt.Sprintf("frontend_assets.txt: want name, file and url in %q", line))
+		}
+
+		assets = append(assets, FrontendAsset{Name: fields[0], File: fields[1], CDN: fields[2]})
+	}
+
+	return assets
+}
+
+// StaticFiles serves an embedded static directory under content-hashed
+// URLs, e.g. /static/pico.min.css is also served as /static/pico.min.1a2b3c4d.css.
+// Hashed URLs change whenever the content does, so they can be cached forever.
+type StaticFiles struct {
+	fsys   fs.FS
+	hashed map[string]string
+	files  map[string]string
+	etags  map[string]string
+}
+
+func NewStaticFiles(fsys fs.FS) (*StaticFiles, error) {
+	sf := &StaticFiles{
+		fsys,
+		map[string]string{},
+		map[string]string{},
+		map[string]string{},
+	}
+
+	err := fs.WalkDir(fsys, "static", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		ext := path.Ext(p)
+		hashed := fmt.Sprintf("%s.%s%s", strings.TrimSuffix(p, ext), hex.EncodeToString(sum[:4]), ext)
+
+		sf.hashed["/"+p] = "/" + hashed
+		sf.files["/"+hashed] = p
+		sf.etags[p] = `"` + hex.EncodeToString(sum[:16]) + `"`
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sf, nil
+}
+
+// URL returns the hashed URL for a static path such as /static/hamburger_small.png.
+// Paths that are not in the embedded directory are returned unchanged.
+func (sf *StaticFiles) URL(p string) string {
+	if hashed, ok := sf.hashed[p]; ok {
+		return hashed
+	}
+
+	return p
+}
+
+// URLs lists the hashed URL of every static file.
+func (sf *StaticFiles) URLs() []string {
+	return slices.Sorted(maps.Values(sf.hashed))
+}
+
+func (sf *StaticFiles) Has(p string) bool {
+	_, ok := sf.hashed["/"+p]
+	return ok
+}
+
+// ServeHTTP serves hashed URLs with a year-long immutable cache and plain
+// URLs with no-cache, so browsers revalidate them. Both carry an ETag, since
+// embedded files have no modification time to send as Last-Modified.
+func (sf *StaticFiles) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if file, ok := sf.files[r.URL.Path]; ok {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("ETag", sf.etags[file])
+		http.ServeFileFS(w, r, sf.fsys, file)
+		return
+	}
+
+	if etag, ok := sf.etags[strings.TrimPrefix(r.URL.Path, "/")]; ok {
+		w.Header().Set("ETag", etag)
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	http.FileServerFS(sf.fsys).ServeHTTP(w, r)
+}
+
+var (
+	staticFiles = &StaticFiles{hashed: map[string]string{}, files: map[string]string{}, etags: map[string]string{}}
+	useEmbedded = false
+)
+
+// UseStaticFiles sets the files and the frontend asset mode used by StaticURL
+// and AssetURL. Embedded mode requires the vendored assets. Auto mode, the
+// default, uses them when they have been embedded and the pinned CDN copies
+// otherwise. It reports whether the embedded assets are in use.
+func UseStaticFiles(sf *StaticFiles, mode string) (bool, error) {
+	missing := []string{}
+	for _, a := range FrontendAssets {
+		if !sf.Has(a.File) {
+			missing = append(missing, a.File)
+		}
+	}
+
+	switch mode {
+	case AssetsEmbedded:
+		if len(missing) > 0 {
+			return false, fmt.Errorf("assets: missing %s, run ./vendor.sh and rebuild", strings.Join(missing, ", "))
+		}
+
+		useEmbedded = true
+
+	case AssetsAuto:
+		useEmbedded = len(missing) == 0
+
+	case AssetsCDN:
+		useEmbedded = false
+
+	default:
+		return false, fmt.Errorf("assets: invalid mode %q", mode)
+	}
+
+	staticFiles = sf
+
+	return useEmbedded, nil
+}
+
+func StaticURL(p string) string {
+	return staticFiles.URL(p)
+}
+
+func AssetURL(name string) string {
+	for _, a := range FrontendAssets {
+		if a.Name == name {
+			return Ternary(useEmbedded, staticFiles.URL("/"+a.File), a.CDN)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,107 @@
+package internal_test
+
+import (
+	"cooking-with-datastar/cmd/internal"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticFiles(t *testing.T) {
+	sf, err := internal.NewStaticFiles(fstest.MapFS{
+		"static/app.css": {Data: []byte("body { color: tomato; }")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashed := sf.URL("/static/app.css")
+	if hashed == "/static/app.css" || !strings.HasPrefix(hashed, "/static/app.") || !strings.HasSuffix(hashed, ".css") {
+		t.Fatalf("want a content-hashed URL, got '%s'", hashed)
+	}
+
+	if sf.URL("/static/missing.png") != "/static/missing.png" {
+		t.Log("want unknown paths unchanged")
+		t.Fail()
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		status       int
+		cacheControl string
+	}{
+		{"hashed", hashed, http.StatusOK, "public, max-age=31536000, immutable"},
+		{"plain", "/static/app.css", http.StatusOK, "no-cache"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			sf.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if w.Code != tc.status || w.Header().Get("Cache-Control") != tc.cacheControl {
+				t.Logf("want %d '%s', got %d '%s'", tc.status, tc.cacheControl, w.Code, w.Header().Get("Cache-Control"))
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestStaticFilesETag(t *testing.T) {
+	sf, err := internal.NewStaticFiles(fstest.MapFS{
+		"static/app.css": {Data: []byte("body { color: tomato; }")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	sf.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/static/app.css", nil))
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("want an ETag")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/static/app.css", nil)
+	req.Header.Set("If-None-Match", etag)
+
+	w = httptest.NewRecorder()
+	sf.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotModified {
+		t.Logf("want %d, got %d", http.StatusNotModified, w.Code)
+		t.Fail()
+	}
+}
+
+func TestUseStaticFilesEmbeddedRequiresVendoredAssets(t *testing.T) {
+	sf, err := internal.NewStaticFiles(fstest.MapFS{
+		"static/hamburger_small.png": {Data: []byte("png")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = internal.UseStaticFiles(sf, internal.AssetsEmbedded)
+	if err == nil {
+		t.Log("want an error when the vendored assets are missing")
+		t.Fail()
+	}
+}
+
+func TestFrontendAssets(t *testing.T) {
+	if len(internal.FrontendAssets) == 0 {
+		t.Fatal("want the pinned frontend assets")
+	}
+
+	for _, a := range internal.FrontendAssets {
+		if !strings.HasPrefix(a.File, "static/vendor/") || !strings.HasPrefix(a.CDN, "https://") {
+			t.Logf("want a vendored file and a CDN URL, got %+v", a)
+			t.Fail()
+		}
+	}
+}
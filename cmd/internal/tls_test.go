@@ -0,0 +1,125 @@
+package internal_test
+
+import (
+	"cooking-with-datastar/cmd/internal"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestEnsureSelfSignedCert(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "tls", "cert.pem")
+	keyPath := filepath.Join(dir, "tls", "key.pem")
+
+	created, err := internal.EnsureSelfSignedCert(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !created {
+		t.Log("want a certificate to be created")
+		t.Fail()
+	}
+
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0o600 {
+		t.Logf("want the key to be private, got %s", info.Mode().Perm())
+		t.Fail()
+	}
+
+	created, err = internal.EnsureSelfSignedCert(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created {
+		t.Log("want the existing certificate to be reused")
+		t.Fail()
+	}
+
+	cfg, err := internal.TLSConfig(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Contains(cfg.NextProtos, "h2") {
+		t.Logf("want h2 to be offered, got %v", cfg.NextProtos)
+		t.Fail()
+	}
+
+	leaf, err := x509.ParseCertificate(cfg.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, host := range []string{"localhost", "127.0.0.1"} {
+		err := leaf.VerifyHostname(host)
+		if err != nil {
+			t.Logf("want the certificate to cover %s, got '%s'", host, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestServeHTTP2(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+
+	_, err := internal.EnsureSelfSignedCert(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := internal.TLSConfig(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := http.Server{
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		Protocols: internal.Protocols(),
+		TLSConfig: cfg,
+	}
+	go server.ServeTLS(ln, "", "")
+	defer server.Close()
+
+	pem, err := os.ReadFile(certPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(pem)
+
+	client := http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots},
+		ForceAttemptHTTP2: true,
+	}}
+
+	resp, err := client.Get("https://" + ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Logf("want HTTP/2, got %s", resp.Proto)
+		t.Fail()
+	}
+}
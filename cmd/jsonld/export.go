@@ -0,0 +1,126 @@
+package jsonld
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/i18n"
+	"cooking-with-datastar/cmd/recipes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type of a JSON-LD response.
+const ContentType = "application/ld+json"
+
+// Recipe is the schema.org Recipe this app publishes for a dish.
+type Recipe struct {
+	Context            string      `json:"@context"`
+	Type               string      `json:"@type"`
+	Name               string      `json:"name"`
+	URL                string      `json:"url,omitempty"`
+	Image              string      `json:"image,omitempty"`
+	InLanguage         string      `json:"inLanguage,omitempty"`
+	CookTime           string      `json:"cookTime"`
+	CookingMethod      string      `json:"cookingMethod"`
+	RecipeIngredient   []string    `json:"recipeIngredient"`
+	RecipeInstructions []HowToStep `json:"recipeInstructions"`
+}
+
+type HowToStep struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+// Export describes a recipe, which should already be localized, in the
+// request's locale. Relative URLs are resolved against base.
+func Export(ctx context.Context, r recipes.Recipe, base string) Recipe {
+	ingredients := []string{}
+	for _, i := range r.ListIngredients() {
+		ingredients = append(ingredients, i.Description)
+	}
+
+	// Recipes are validated when registered, so sorting cannot fail on one
+	// that is being shown.
+	tasks, err := recipes.SortTasks(r.ListPrepTasks())
+	if err != nil {
+		tasks = r.ListPrepTasks()
+	}
+
+	steps := []HowToStep{}
+	for _, t := range tasks {
+		steps = append(steps, HowToStep{"HowToStep", i18n.Label(ctx, r, t.Name), t.Description})
+	}
+
+	method := r.GetCookingMethod()
+	steps = append(steps, HowToStep{"HowToStep", i18n.Label(ctx, r, method.Name), method.Description})
+
+	return Recipe{
+		Context:            "https://schema.org",
+		Type:               "Recipe",
+		Name:               i18n.Title(ctx, r),
+		URL:                resolve(base, "/recipe/"+r.String()),
+		Image:              resolve(base, r.GetImageSrc()),
+		InLanguage:         i18n.Locale(ctx),
+		CookTime:           FormatDuration(method.CookTime),
+		CookingMethod:      i18n.Label(ctx, r, method.Name),
+		RecipeIngredient:   ingredients,
+		RecipeInstructions: steps,
+	}
+}
+
+// BaseURL is the scheme and host the request was made to.
+func BaseURL(req *http.Request) string {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + req.Host
+}
+
+func resolve(base string, ref string) string {
+	if ref == "" {
+		return ""
+	}
+
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+
+	return b.ResolveReference(r).String()
+}
+
+// FormatDuration writes d as an ISO-8601 duration such as "PT1H30M",
+// dropping fractions of a second.
+func FormatDuration(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	if d <= 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	b.WriteString("PT")
+
+	for _, u := range []struct {
+		unit   time.Duration
+		letter string
+	}{{time.Hour, "H"}, {time.Minute, "M"}, {time.Second, "S"}} {
+		n := d / u.unit
+		d -= n * u.unit
+
+		if n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, u.letter)
+		}
+	}
+
+	return b.String()
+}
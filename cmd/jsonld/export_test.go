@@ -0,0 +1,139 @@
+package jsonld_test
+
+import (
+	"bytes"
+	"context"
+	"cooking-with-datastar/cmd/i18n"
+	"cooking-with-datastar/cmd/jsonld"
+	"cooking-with-datastar/cmd/recipes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		input    time.Duration
+		expected string
+	}{
+		{0, "PT0S"},
+		{10 * time.Second, "PT10S"},
+		{45 * time.Minute, "PT45M"},
+		{90 * time.Minute, "PT1H30M"},
+		{time.Hour + 5*time.Minute + 10*time.Second, "PT1H5M10S"},
+		{26 * time.Hour, "PT26H"},
+		{1500 * time.Millisecond, "PT1S"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			got := jsonld.FormatDuration(tt.input)
+			if got != tt.expected {
+				t.Logf("want '%s', got '%s'", tt.expected, got)
+				t.Fail()
+			}
+
+			back, err := jsonld.ParseDuration(got)
+			if err != nil || back != tt.input.Truncate(time.Second) {
+				t.Logf("want '%s', got '%s' (%v)", tt.input.Truncate(time.Second), back, err)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+
+	got := jsonld.Export(ctx, recipes.BuffaloChickenDip, "https://cooking.example")
+
+	if got.Name != "Buffalo chicken dip" {
+		t.Logf("want '%s', got '%s'", "Buffalo chicken dip", got.Name)
+		t.Fail()
+	}
+
+	if got.URL != "https://cooking.example/recipe/buffalo-chicken-dip" {
+		t.Logf("want '%s', got '%s'", "https://cooking.example/recipe/buffalo-chicken-dip", got.URL)
+		t.Fail()
+	}
+
+	if got.Image != "https://cooking.example/static/buffalo_chicken_dip_pixel_art_small.png" {
+		t.Logf("want '%s', got '%s'", "https://cooking.example/static/buffalo_chicken_dip_pixel_art_small.png", got.Image)
+		t.Fail()
+	}
+
+	if got.CookTime != "PT10S" {
+		t.Logf("want '%s', got '%s'", "PT10S", got.CookTime)
+		t.Fail()
+	}
+
+	if len(got.RecipeIngredient) != len(recipes.BuffaloChickenDip.Ingredients) {
+		t.Logf("want %d ingredients, got %d", len(recipes.BuffaloChickenDip.Ingredients), len(got.RecipeIngredient))
+		t.Fail()
+	}
+
+	// Every task plus the cooking method, with dependencies first.
+	steps := got.RecipeInstructions
+	if len(steps) != len(recipes.BuffaloChickenDip.Tasks)+1 {
+		t.Fatalf("want %d steps, got %d", len(recipes.BuffaloChickenDip.Tasks)+1, len(steps))
+	}
+
+	if steps[0].Name != "Cook the chicken" || steps[1].Name != "Shred" {
+		t.Logf("want '%s' then '%s', got '%s' then '%s'", "Cook the chicken", "Shred", steps[0].Name, steps[1].Name)
+		t.Fail()
+	}
+
+	if steps[len(steps)-1].Text != recipes.BuffaloChickenDip.CookingMethod.Description {
+		t.Logf("want '%s', got '%s'", recipes.BuffaloChickenDip.CookingMethod.Description, steps[len(steps)-1].Text)
+		t.Fail()
+	}
+}
+
+func TestExportLocalized(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), "es")
+
+	got := jsonld.Export(ctx, i18n.Localize(ctx, recipes.PulledPork), "https://cooking.example")
+
+	if got.InLanguage != "es" {
+		t.Logf("want '%s', got '%s'", "es", got.InLanguage)
+		t.Fail()
+	}
+
+	if got.Name == "Pulled Pork" {
+		t.Logf("want a Spanish title, got '%s'", got.Name)
+		t.Fail()
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	for _, r := range recipes.ListRecipes() {
+		t.Run(r.Name, func(t *testing.T) {
+			data, err := json.Marshal(jsonld.Export(context.Background(), r, "https://cooking.example"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := jsonld.Import(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Name != r.Name {
+				t.Logf("want '%s', got '%s'", r.Name, got.Name)
+				t.Fail()
+			}
+
+			if got.CookingMethod.CookTime != r.CookingMethod.CookTime {
+				t.Logf("want '%s', got '%s'", r.CookingMethod.CookTime, got.CookingMethod.CookTime)
+				t.Fail()
+			}
+
+			for i, ingredient := range r.Ingredients {
+				if got.Ingredients[i].Description != ingredient.Description {
+					t.Logf("want '%s', got '%s'", ingredient.Description, got.Ingredients[i].Description)
+					t.Fail()
+				}
+			}
+		})
+	}
+}
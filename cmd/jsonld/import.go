@@ -0,0 +1,514 @@
+// Package jsonld converts between this app's recipes and schema.org Recipe
+// JSON-LD, the structured data most recipe sites embed in their pages.
+package jsonld
+
+import (
+	"bytes"
+	"cooking-with-datastar/cmd/recipes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MaxImportSize bounds a recipe page, which is mostly markup around a few
+// kilobytes of JSON-LD.
+const MaxImportSize = 4 << 20
+
+var ErrNoRecipe = errors.New("jsonld: no schema.org Recipe found")
+
+// node is a JSON-LD object. Properties are left raw because schema.org allows
+// most of them to be a string, an object or an array of either.
+type node map[string]json.RawMessage
+
+var scriptPattern = regexp.MustCompile(`(?is)<script[^>]*type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+
+// Import reads an HTML page or a JSON-LD document and converts the first
+// schema.org Recipe in it. The result is validated but not registered.
+func Import(r io.Reader) (recipes.Recipe, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return recipes.Recipe{}, err
+	}
+
+	docs := [][]byte{data}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '<' {
+		docs = nil
+		for _, m := range scriptPattern.FindAllSubmatch(data, -1) {
+			docs = append(docs, m[1])
+		}
+	}
+
+	for _, doc := range docs {
+		var v any
+		err := json.Unmarshal(doc, &v)
+		if err != nil {
+			// Pages often carry several scripts; one broken block should not
+			// hide a good Recipe in another.
+			if len(docs) > 1 {
+				continue
+			}
+
+			return recipes.Recipe{}, fmt.Errorf("jsonld: %w", err)
+		}
+
+		n, ok := findRecipe(v)
+		if !ok {
+			continue
+		}
+
+		return convert(n)
+	}
+
+	return recipes.Recipe{}, ErrNoRecipe
+}
+
+// findRecipe walks arrays and @graph lists looking for a node whose @type is
+// or includes Recipe.
+func findRecipe(v any) (node, bool) {
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			n, ok := findRecipe(item)
+			if ok {
+				return n, true
+			}
+		}
+
+	case map[string]any:
+		if isType(v["@type"], "Recipe") {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, false
+			}
+
+			var n node
+			err = json.Unmarshal(data, &n)
+			if err != nil {
+				return nil, false
+			}
+
+			return n, true
+		}
+
+		if graph, ok := v["@graph"]; ok {
+			return findRecipe(graph)
+		}
+	}
+
+	return nil, false
+}
+
+func isType(v any, name string) bool {
+	switch v := v.(type) {
+	case string:
+		return v == name || v == "http://schema.org/"+name || v == "https://schema.org/"+name
+
+	case []any:
+		return slices.ContainsFunc(v, func(t any) bool { return isType(t, name) })
+	}
+
+	return false
+}
+
+func convert(n node) (recipes.Recipe, error) {
+	title := n.text("name")
+	name := slugify(title, 0)
+	if name == "" {
+		return recipes.Recipe{}, errors.New("jsonld: recipe is missing a name")
+	}
+
+	cookTime, err := n.duration("cookTime")
+	if err == nil && cookTime == 0 {
+		cookTime, err = n.duration("totalTime")
+	}
+	if err != nil {
+		return recipes.Recipe{}, err
+	}
+
+	ingredients := []recipes.Ingredient{}
+	ingredientNames := map[string]bool{}
+	for _, text := range n.texts("recipeIngredient") {
+		ingredients = append(ingredients, recipes.Ingredient{
+			Name:        unique(ingredientName(text), "ingredient", ingredientNames),
+			Description: text,
+			Optional:    optionalPattern.MatchString(text),
+		})
+	}
+
+	tasks := []recipes.Task{}
+	taskNames := map[string]bool{}
+	for _, step := range n.steps("recipeInstructions") {
+		// Instructions are an ordered list, so each task waits on the one
+		// before it.
+		dependencies := []string{}
+		if len(tasks) > 0 {
+			dependencies = append(dependencies, tasks[len(tasks)-1].Name)
+		}
+
+		tasks = append(tasks, recipes.Task{Name: unique(slugify(step.name, 3), "task", taskNames), Description: step.text, Dependencies: dependencies})
+	}
+
+	// A recipe without a cook time, such as a salad, is served as soon as
+	// it is prepared.
+	method := slugify(n.text("cookingMethod"), 3)
+	description := "Serve."
+	if d := describeDuration(cookTime); d != "" {
+		description = "Cook for " + d + "."
+	} else if cookTime > 0 {
+		description = "Cook briefly."
+	}
+	if method == "" && cookTime == 0 {
+		method = "serve"
+	}
+	if method == "" {
+		method = "cook"
+	}
+	method = unique(method, "cook", taskNames)
+
+	r := recipes.Recipe{
+		Name:        name,
+		ImageSrc:    n.image("image"),
+		Ingredients: ingredients,
+		Tasks:       tasks,
+		CookingMethod: recipes.CookingMethod{
+			Name:        method,
+			Description: description,
+			CookTime:    cookTime,
+		},
+	}
+
+	err = r.Validate()
+	if err != nil {
+		return recipes.Recipe{}, err
+	}
+
+	return r, nil
+}
+
+func (n node) text(key string) string {
+	texts := n.texts(key)
+	if len(texts) == 0 {
+		return ""
+	}
+
+	return texts[0]
+}
+
+// texts reads a property that is a string or a list of strings.
+func (n node) texts(key string) []string {
+	raw, ok := n[key]
+	if !ok {
+		return nil
+	}
+
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		s = clean(s)
+		if s == "" {
+			return nil
+		}
+
+		return []string{s}
+	}
+
+	var list []any
+	if json.Unmarshal(raw, &list) != nil {
+		return nil
+	}
+
+	texts := []string{}
+	for _, item := range list {
+		if s, ok := item.(string); ok && clean(s) != "" {
+			texts = append(texts, clean(s))
+		}
+	}
+
+	return texts
+}
+
+type step struct {
+	name string
+	text string
+}
+
+// steps flattens recipeInstructions, which may be a block of text, a list of
+// strings, HowToStep objects, or HowToSection objects holding more steps.
+func (n node) steps(key string) []step {
+	raw, ok := n[key]
+	if !ok {
+		return nil
+	}
+
+	var v any
+	if json.Unmarshal(raw, &v) != nil {
+		return nil
+	}
+
+	return collectSteps(v)
+}
+
+func collectSteps(v any) []step {
+	steps := []step{}
+
+	switch v := v.(type) {
+	case string:
+		for _, line := range strings.Split(stripTags(v), "\n") {
+			line = clean(line)
+			if line != "" {
+				steps = append(steps, step{line, line})
+			}
+		}
+
+	case []any:
+		for _, item := range v {
+			steps = append(steps, collectSteps(item)...)
+		}
+
+	case map[string]any:
+		if items, ok := v["itemListElement"]; ok {
+			return collectSteps(items)
+		}
+
+		text, _ := v["text"].(string)
+		text = clean(stripTags(text))
+		if text == "" {
+			return steps
+		}
+
+		// Sites often repeat the text as the name, or truncate it there, so
+		// a name is only used when it is short enough to be a heading.
+		name, _ := v["name"].(string)
+		name = clean(name)
+		if name == "" || len(strings.Fields(name)) > 5 {
+			name = text
+		}
+
+		steps = append(steps, step{name, text})
+	}
+
+	return steps
+}
+
+// image reads a property that is a URL, an ImageObject, or a list of either,
+// and returns the first absolute http(s) URL. Anything else is left out, since
+// the page it came from is not trusted.
+func (n node) image(key string) string {
+	raw, ok := n[key]
+	if !ok {
+		return ""
+	}
+
+	var v any
+	if json.Unmarshal(raw, &v) != nil {
+		return ""
+	}
+
+	return firstURL(v)
+}
+
+func firstURL(v any) string {
+	switch v := v.(type) {
+	case string:
+		u, err := url.Parse(strings.TrimSpace(v))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ""
+		}
+
+		return u.String()
+
+	case []any:
+		for _, item := range v {
+			url := firstURL(item)
+			if url != "" {
+				return url
+			}
+		}
+
+	case map[string]any:
+		if url, ok := v["url"]; ok {
+			return firstURL(url)
+		}
+
+		return firstURL(v["contentUrl"])
+	}
+
+	return ""
+}
+
+func (n node) duration(key string) (time.Duration, error) {
+	s := n.text(key)
+	if s == "" {
+		return 0, nil
+	}
+
+	d, err := ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("jsonld: %s: %w", key, err)
+	}
+
+	return d, nil
+}
+
+var durationPattern = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)W)?(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// ParseDuration reads an ISO-8601 duration such as "PT1H30M". Years and
+// months are rejected because their length is ambiguous.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	m := durationPattern.FindStringSubmatch(s)
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid ISO-8601 duration %q", s)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] == "" {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO-8601 duration %q", s)
+		}
+
+		d += time.Duration(v * float64(unit))
+	}
+
+	return d, nil
+}
+
+func describeDuration(d time.Duration) string {
+	parts := []string{}
+
+	for _, u := range []struct {
+		unit time.Duration
+		name string
+	}{{time.Hour, "hour"}, {time.Minute, "minute"}, {time.Second, "second"}} {
+		n := d / u.unit
+		d -= n * u.unit
+
+		switch {
+		case n == 1:
+			parts = append(parts, "1 "+u.name)
+
+		case n > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", n, u.name))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// units are dropped from ingredient names so "2 cups white sugar" becomes
+// "white-sugar" rather than "cups-white-sugar".
+var units = map[string]bool{
+	"c": true, "cup": true, "cups": true,
+	"tsp": true, "teaspoon": true, "teaspoons": true,
+	"tbsp": true, "tablespoon": true, "tablespoons": true,
+	"oz": true, "ounce": true, "ounces": true,
+	"lb": true, "lbs": true, "pound": true, "pounds": true,
+	"g": true, "gram": true, "grams": true, "kg": true,
+	"ml": true, "l": true, "liter": true, "liters": true, "litre": true, "litres": true,
+	"pinch": true, "dash": true, "clove": true, "cloves": true,
+	"can": true, "cans": true, "package": true, "packages": true,
+	"of": true,
+}
+
+// optionalPattern finds ingredients such as "1/4 cup pecans (optional)".
+var optionalPattern = regexp.MustCompile(`(?i)\boptional\b`)
+
+var parenthesesPattern = regexp.MustCompile(`\([^)]*\)`)
+
+// ingredientName keeps the words before any comma, less asides in
+// parentheses and the leading quantity and unit.
+func ingredientName(text string) string {
+	text = parenthesesPattern.ReplaceAllString(text, "")
+	if i := strings.Index(text, ","); i > 0 {
+		text = text[:i]
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-'
+	})
+
+	for len(words) > 1 && units[strings.Trim(words[0], "-")] {
+		words = words[1:]
+	}
+
+	if len(words) > 3 {
+		words = words[len(words)-3:]
+	}
+
+	return slugify(strings.Join(words, " "), 0)
+}
+
+// slugify makes a lowercase, hyphenated name from at most limit words (zero
+// means no limit). Slugs start with a letter so that ToCamelCase turns them
+// into valid signal names.
+func slugify(text string, limit int) string {
+	words := strings.FieldsFunc(accents.Replace(strings.ToLower(text)), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9')
+	})
+
+	for len(words) > 0 && !unicode.IsLetter(rune(words[0][0])) {
+		words = words[1:]
+	}
+
+	if limit > 0 && len(words) > limit {
+		words = words[:limit]
+	}
+
+	return strings.Join(words, "-")
+}
+
+// unique returns name, or name with a numbered suffix when it is already
+// taken, and records it in taken.
+func unique(name string, fallback string, taken map[string]bool) string {
+	if name == "" {
+		name = fallback
+	}
+
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+
+	taken[candidate] = true
+
+	return candidate
+}
+
+// accents folds the common Latin accented letters, since names end up in
+// cookie names, which must be ASCII.
+var accents = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a",
+	"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ß", "ss",
+)
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+func stripTags(s string) string {
+	s = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n", "</li>", "\n").Replace(s)
+	return tagPattern.ReplaceAllString(s, "")
+}
+
+// clean unescapes entities, which many sites double-encode into their JSON,
+// and collapses whitespace.
+func clean(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
@@ -0,0 +1,301 @@
+package jsonld_test
+
+import (
+	"cooking-with-datastar/cmd/internal"
+	"cooking-with-datastar/cmd/jsonld"
+	"cooking-with-datastar/cmd/recipes"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func importFixture(t *testing.T, name string) recipes.Recipe {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r, err := jsonld.Import(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		name        string
+		imageSrc    string
+		ingredients []string
+		tasks       []string
+		method      string
+		cookTime    time.Duration
+	}{
+		{
+			"banana-bread.html",
+			"banana-bread",
+			"https://kitchen.example/images/banana-bread.jpg",
+			[]string{"all-purpose-flour", "baking-soda", "salt", "butter", "brown-sugar", "eggs", "mashed-overripe-bananas"},
+			[]string{"preheat-oven-to", "combine-flour-baking", "beat-brown-sugar", "stir-banana-mixture"},
+			"baking",
+			time.Hour + 5*time.Minute,
+		},
+		{
+			"weeknight-chili.json",
+			"weeknight-chili",
+			"https://chili.example/images/chili.png",
+			[]string{"ground-beef", "onion", "garlic", "kidney-beans", "black-beans", "chili-powder", "chili-powder-2", "creme-fraiche"},
+			[]string{"brown-the-beef", "soften", "add-the-beans"},
+			"cook",
+			45 * time.Minute,
+		},
+		{
+			"overnight-oats.json",
+			"overnight-oats",
+			"",
+			[]string{"rolled-oats", "milk", "maple-syrup"},
+			[]string{"stir-everything-together", "cover-and-refrigerate"},
+			"cook",
+			8 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			r := importFixture(t, tt.fixture)
+
+			if r.Name != tt.name {
+				t.Logf("want '%s', got '%s'", tt.name, r.Name)
+				t.Fail()
+			}
+
+			if r.ImageSrc != tt.imageSrc {
+				t.Logf("want '%s', got '%s'", tt.imageSrc, r.ImageSrc)
+				t.Fail()
+			}
+
+			ingredients := []string{}
+			for _, i := range r.Ingredients {
+				ingredients = append(ingredients, i.Name)
+			}
+			if !slices.Equal(ingredients, tt.ingredients) {
+				t.Logf("want %v, got %v", tt.ingredients, ingredients)
+				t.Fail()
+			}
+
+			tasks := []string{}
+			for _, task := range r.Tasks {
+				tasks = append(tasks, task.Name)
+			}
+			if !slices.Equal(tasks, tt.tasks) {
+				t.Logf("want %v, got %v", tt.tasks, tasks)
+				t.Fail()
+			}
+
+			if r.CookingMethod.Name != tt.method {
+				t.Logf("want '%s', got '%s'", tt.method, r.CookingMethod.Name)
+				t.Fail()
+			}
+
+			if r.CookingMethod.CookTime != tt.cookTime {
+				t.Logf("want '%s', got '%s'", tt.cookTime, r.CookingMethod.CookTime)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestImportOptional(t *testing.T) {
+	r := importFixture(t, "overnight-oats.json")
+
+	for _, i := range r.Ingredients {
+		if i.Optional != (i.Name == "maple-syrup") {
+			t.Logf("%s: want optional %v, got %v", i.Name, i.Name == "maple-syrup", i.Optional)
+			t.Fail()
+		}
+	}
+}
+
+func TestImportKeepsText(t *testing.T) {
+	r := importFixture(t, "weeknight-chili.json")
+
+	if got := r.Ingredients[1].Description; got != "1 onion, chopped" {
+		t.Logf("want '%s', got '%s'", "1 onion, chopped", got)
+		t.Fail()
+	}
+
+	if got := r.Ingredients[7].Description; got != "Crème fraîche" {
+		t.Logf("want '%s', got '%s'", "Crème fraîche", got)
+		t.Fail()
+	}
+
+	want := "Add the onion and garlic and cook until soft."
+	if got := r.Tasks[1].Description; got != want {
+		t.Logf("want '%s', got '%s'", want, got)
+		t.Fail()
+	}
+}
+
+func TestImportChainsTasks(t *testing.T) {
+	r := importFixture(t, "banana-bread.html")
+
+	if len(r.Tasks[0].Dependencies) != 0 {
+		t.Logf("want no dependencies, got %v", r.Tasks[0].Dependencies)
+		t.Fail()
+	}
+
+	for i, task := range r.Tasks[1:] {
+		want := []string{r.Tasks[i].Name}
+		if !slices.Equal(task.Dependencies, want) {
+			t.Logf("want %v, got %v", want, task.Dependencies)
+			t.Fail()
+		}
+	}
+}
+
+var signalPattern = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+
+func TestImportSignalNames(t *testing.T) {
+	for _, fixture := range []string{"banana-bread.html", "weeknight-chili.json", "overnight-oats.json"} {
+		r := importFixture(t, fixture)
+
+		names := []string{r.Name, r.CookingMethod.Name}
+		for _, i := range r.Ingredients {
+			names = append(names, i.Name)
+		}
+		for _, task := range r.Tasks {
+			names = append(names, task.Name)
+		}
+
+		for _, name := range names {
+			signal := internal.ToCamelCase(name)
+			if !signalPattern.MatchString(signal) {
+				t.Logf("%s: '%s' makes the signal '%s'", fixture, name, signal)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"no recipe", `<html><script type="application/ld+json">{"@type": "WebSite"}</script></html>`, jsonld.ErrNoRecipe},
+		{"no scripts", `<html><body>Soup</body></html>`, jsonld.ErrNoRecipe},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jsonld.Import(strings.NewReader(tt.input))
+			if !errors.Is(err, tt.want) {
+				t.Logf("want '%v', got '%v'", tt.want, err)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestImportCookTime(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		method      string
+		description string
+		cookTime    time.Duration
+	}{
+		{"no cook time", `{"@type": "Recipe", "name": "Salad", "prepTime": "PT10M", "recipeIngredient": ["1 head lettuce"]}`, "serve", "Serve.", 0},
+		{"total time", `{"@type": "Recipe", "name": "Soup", "totalTime": "PT1H"}`, "cook", "Cook for 1 hour.", time.Hour},
+		{"under a second", `{"@type": "Recipe", "name": "Flash", "cookTime": "PT0.5S"}`, "cook", "Cook briefly.", 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := jsonld.Import(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := r.CookingMethod
+			if got.Name != tt.method || got.Description != tt.description || got.CookTime != tt.cookTime {
+				t.Logf("want %s '%s' %v, got %s '%s' %v", tt.method, tt.description, tt.cookTime, got.Name, got.Description, got.CookTime)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		valid    bool
+	}{
+		{"PT45M", 45 * time.Minute, true},
+		{"PT1H30M", 90 * time.Minute, true},
+		{"P0DT8H", 8 * time.Hour, true},
+		{"P1D", 24 * time.Hour, true},
+		{"PT1.5H", 90 * time.Minute, true},
+		{"PT90S", 90 * time.Second, true},
+		{"pt20m", 20 * time.Minute, true},
+		{"P", 0, false},
+		{"PT", 0, false},
+		{"P1M", 0, false},
+		{"20 minutes", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := jsonld.ParseDuration(tt.input)
+			if (err == nil) != tt.valid {
+				t.Logf("want valid %v, got error '%v'", tt.valid, err)
+				t.Fail()
+			}
+
+			if got != tt.expected {
+				t.Logf("want '%s', got '%s'", tt.expected, got)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestImportImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		image    string
+		imageSrc string
+	}{
+		{"absolute", `"https://kitchen.example/soup.jpg"`, "https://kitchen.example/soup.jpg"},
+		{"script", `"javascript:alert(1)"`, ""},
+		{"relative", `"/soup.jpg"`, ""},
+		{"quote", `"https://kitchen.example/\" onerror=\"alert(1)"`, "https://kitchen.example/%22%20onerror=%22alert%281%29"},
+		{"first usable", `["data:image/png;base64,AAAA", {"url": "http://kitchen.example/soup.png"}]`, "http://kitchen.example/soup.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := jsonld.Import(strings.NewReader(`{"@type": "Recipe", "name": "Soup", "image": ` + tt.image + `}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if r.ImageSrc != tt.imageSrc {
+				t.Logf("want '%s', got '%s'", tt.imageSrc, r.ImageSrc)
+				t.Fail()
+			}
+		})
+	}
+}
@@ -1,15 +1,38 @@
 package main
 
 import (
+	"context"
+	"cooking-with-datastar/cmd/admin"
+	"cooking-with-datastar/cmd/cli"
+	"cooking-with-datastar/cmd/config"
+	"cooking-with-datastar/cmd/i18n"
+	"cooking-with-datastar/cmd/images"
 	"cooking-with-datastar/cmd/internal"
+	"cooking-with-datastar/cmd/jsonld"
+	"cooking-with-datastar/cmd/pantry"
+	"cooking-with-datastar/cmd/pdf"
+	"cooking-with-datastar/cmd/pwa"
 	"cooking-with-datastar/cmd/recipes"
+	"cooking-with-datastar/cmd/shopping"
+	adminview "cooking-with-datastar/cmd/view/admin"
 	"cooking-with-datastar/cmd/view/cooking"
+	"cooking-with-datastar/cmd/voice"
 	"embed"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"image"
+	_ "image/png"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/starfederation/datastar-go/datastar"
@@ -19,292 +42,1586 @@ import (
 var Files embed.FS
 
 func main() {
-	port := flag.Int("port", 8080, "A port to listen on")
-	flag.Parse()
+	if len(os.Args) > 1 && os.Args[1] == "recipes" {
+		os.Exit(cli.Run(cli.Env{Stdout: os.Stdout, Stderr: os.Stderr, Getenv: os.Getenv}, os.Args[2:]))
+	}
+
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+
+	logger, err := internal.NewLogger(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
+	if cfg.RecipeDir != "" {
+		err := recipes.LoadDir(cfg.RecipeDir)
+		if err != nil {
+			logger.Error("Cannot load recipes", slog.String("dir", cfg.RecipeDir), slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	// Uploaded images live beside the recipes that use them. Without a
+	// recipe directory they only last until a restart, as the recipes do.
+	imageDir := filepath.Join(cfg.RecipeDir, "images")
+	if cfg.RecipeDir == "" {
+		imageDir, err = os.MkdirTemp("", "cooking-images-")
+		if err != nil {
+			logger.Error("Cannot create image directory", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	imageStore, err := images.NewStore(imageDir)
+	if err != nil {
+		logger.Error("Cannot open image directory", slog.String("dir", imageDir), slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// Pantries are kept beside the recipes too, or in a temporary directory.
+	pantryDir := filepath.Join(cfg.RecipeDir, "pantries")
+	if cfg.RecipeDir == "" {
+		pantryDir, err = os.MkdirTemp("", "cooking-pantries-")
+		if err != nil {
+			logger.Error("Cannot create pantry directory", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	pantryStore, err := pantry.NewStore(pantryDir)
+	if err != nil {
+		logger.Error("Cannot open pantry directory", slog.String("dir", pantryDir), slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cookieOptions := internal.CookieOptions{
+		MaxAge:    cfg.CookieMaxAge,
+		Secret:    []byte(cfg.CookieSecret),
+		TimeScale: cfg.TimeScale,
+	}
 
 	mux := http.NewServeMux()
 
-	mux.Handle("GET /static/", http.FileServerFS(Files))
+	staticFiles, err := internal.NewStaticFiles(Files)
+	if err != nil {
+		logger.Error("Cannot read static files", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	embedded, err := internal.UseStaticFiles(staticFiles, cfg.Assets)
+	if err != nil {
+		logger.Error("Cannot use static files", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	if !embedded && cfg.Assets == internal.AssetsAuto {
+		logger.Warn("Frontend assets are not vendored, loading them from the CDN. Run ./vendor.sh and rebuild to serve them offline.")
+	}
+
+	mux.Handle("GET /static/", staticFiles)
+
+	icons, err := pwa.IconHandler(Files, "static/hamburger_small.png")
+	if err != nil {
+		logger.Error("Cannot render icons", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	mux.Handle("GET /icons/{icon}", icons)
+
+	mux.Handle("GET "+pwa.ManifestURL, pwa.ManifestHandler())
+
+	precache := []string{"/", pwa.ManifestURL}
+	for _, a := range internal.FrontendAssets {
+		precache = append(precache, internal.AssetURL(a.Name))
+	}
+	for _, u := range staticFiles.URLs() {
+		if !slices.Contains(precache, u) {
+			precache = append(precache, u)
+		}
+	}
+	for _, i := range pwa.Icons {
+		precache = append(precache, pwa.IconURL(i.Name))
+	}
+
+	worker, err := pwa.ServiceWorkerHandler(precache, internal.ReadBuildInfo().Revision)
+	if err != nil {
+		logger.Error("Cannot render service worker", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	mux.Handle("GET "+pwa.WorkerURL, worker)
+
+	mux.Handle("GET /metrics", internal.MetricsHandler())
+
+	mux.Handle("GET /healthz", internal.HealthHandler())
+
+	// Cooking progress is kept in cookies, so the stores on disk are the
+	// recipe directory, which edits and imports are saved to, and the image
+	// and pantry directories. The static files are embedded and so always
+	// there.
+	readiness := []internal.ReadinessCheck{
+		{Name: "recipes", Check: checkRecipes},
+		{Name: "images", Check: internal.WritableDir(imageDir)},
+		{Name: "pantries", Check: internal.WritableDir(pantryDir)},
+	}
+	if cfg.RecipeDir != "" {
+		readiness = append(readiness, internal.ReadinessCheck{Name: "recipe-dir", Check: internal.WritableDir(cfg.RecipeDir)})
+	}
+
+	mux.Handle("GET /readyz", internal.ReadyHandler(readiness...))
+
+	mux.Handle("GET /version", internal.VersionHandler())
+
+	mux.HandleFunc("GET /recipes.jsonld", func(w http.ResponseWriter, r *http.Request) {
+		list := []jsonld.Recipe{}
+		for _, recipe := range recipes.ListRecipes() {
+			list = append(list, jsonld.Export(r.Context(), i18n.Localize(r.Context(), recipe), jsonld.BaseURL(r)))
+		}
+
+		writeJSONLD(w, r, list)
+	})
 
 	mux.HandleFunc("GET /recipe/{recipe}", func(w http.ResponseWriter, r *http.Request) {
-		recipe, err := recipes.ParseRecipe(r.PathValue("recipe"))
+		logger := internal.LoggerFromContext(r.Context()).With(slog.String("recipe", r.PathValue("recipe")))
+
+		// Path wildcards match whole segments, so /recipe/{recipe}.jsonld
+		// arrives here.
+		if name, ok := strings.CutSuffix(r.PathValue("recipe"), ".jsonld"); ok {
+			recipe, err := recipes.ParseRecipe(name)
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+
+			writeJSONLD(w, r, jsonld.Export(r.Context(), i18n.Localize(r.Context(), recipe), jsonld.BaseURL(r)))
+			return
+		}
+
+		recipe, err := pinnedRecipe(r, cookieOptions)
 		if err != nil {
 			http.Redirect(w, r, "/", http.StatusSeeOther)
 			return
 		}
 
-		cs := internal.NewCookieStorage(recipe, r)
-
-		cookie, err := cs.GetStepCookie()
+		_, stock, err := userPantry(r, cookieOptions, pantryStore)
 		if err != nil {
 			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
-		http.SetCookie(w, cookie)
 
-		step, err := recipes.ParseRecipeStep(cookie.Value)
+		cs := internal.NewCookieStorage(recipe, r, cookieOptions).WithPantry(stock.Covered(recipe))
+
+		versionCookie, err := cs.GetVersionCookie()
 		if err != nil {
 			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
+		versionCookie.Value = strconv.Itoa(recipe.Version)
+		cs.SetCookie(w, versionCookie)
 
-		gatheredIngredients, err := cs.GetGatheredIngredients()
+		cookCookie, err := cs.GetCookCookie()
 		if err != nil {
 			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
+		cs.SetCookie(w, cookCookie)
 
-		finishedTasks, err := cs.GetFinishedTasks()
+		// The ingredients in the pantry start out gathered, so they are kept
+		// for the rest of the cook.
+		ingredientsCookie, err := cs.GetIngredientsCookie()
 		if err != nil {
 			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
+		cs.SetCookie(w, ingredientsCookie)
 
-		finishedCooking, err := cs.FinishedCooking()
+		gatheredIngredients, err := cs.GetGatheredIngredients()
 		if err != nil {
 			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
 
-		w.Header().Set("Content-Type", "text/html")
-
-		cooking.Recipe(recipe, step, gatheredIngredients, finishedTasks, finishedCooking).Render(r.Context(), w)
-	})
+		started := cs.Started()
 
-	mux.HandleFunc("PATCH /gather/{recipe}", func(w http.ResponseWriter, r *http.Request) {
-		recipe, err := recipes.ParseRecipe(r.PathValue("recipe"))
+		cookie, err := cs.GetStepCookie()
 		if err != nil {
 			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
 
-		err = r.ParseForm()
+		step, err := recipes.ParseRecipeStep(cookie.Value)
 		if err != nil {
+			internal.DecodeErrors.Inc("step")
 			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
 
-		cs := internal.NewCookieStorage(recipe, r)
+		// With everything in the pantry there is nothing left to gather.
+		if step == recipes.Gather && recipe.FinishedGathering(gatheredIngredients) {
+			cookie, err = cs.ToNextStep()
+			if err != nil {
+				logger.Error(err.Error())
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			step = step.GetNextStep()
+		}
+		cs.SetCookie(w, cookie)
+
+		if !started {
+			internal.RecipeStarts.Inc(recipe.String())
+		}
 
-		cookie, err := cs.GatherIngredients(r.Form)
+		finishedTasks, err := cs.GetFinishedTasks()
 		if err != nil {
 			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
-		http.SetCookie(w, cookie)
 
-		finished, err := cs.FinishedGatheringIngredients(cookie)
+		finishedCooking, err := cs.FinishedCooking()
 		if err != nil {
 			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
 
-		if !finished {
+		prefs, err := internal.NewSessionStorage(r, cookieOptions).GetPreferences()
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+
+		localized := i18n.Localize(r.Context(), recipe)
+
+		cooking.Recipe(localized, step, gatheredIngredients, stock, finishedTasks, finishedCooking, prefs, jsonld.Export(r.Context(), localized, jsonld.BaseURL(r))).Render(r.Context(), w)
+	})
+
+	mux.HandleFunc("POST /locale", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
+
+		locale := r.FormValue("locale")
+		if !slices.Contains(i18n.Locales, locale) {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
 
-		cookie, err = cs.ToNextStep()
+		cs := internal.NewSessionStorage(r, cookieOptions)
+
+		cookie, err := cs.SetLocale(locale)
 		if err != nil {
 			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
+		cs.SetCookie(w, cookie)
 
-		http.SetCookie(w, cookie)
-
-		http.Redirect(w, r, "/recipe/"+recipe.String(), http.StatusSeeOther)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
 	})
 
-	mux.HandleFunc("PATCH /prep/{recipe}/{task}", func(w http.ResponseWriter, r *http.Request) {
-		recipe, err := recipes.ParseRecipe(r.PathValue("recipe"))
+	protected := func(h http.HandlerFunc) http.Handler {
+		return internal.RequireAdmin(cfg.AdminPassword, h)
+	}
+
+	mux.Handle("POST /import", protected(func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
+
+		r.Body = http.MaxBytesReader(w, r.Body, jsonld.MaxImportSize)
+
+		file, _, err := r.FormFile("recipe")
 		if err != nil {
-			logger.Error("Cannot parse recipe", slog.String("error", err.Error()))
+			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
+		defer file.Close()
 
-		task, err := recipes.ParseTask(recipe, r.PathValue("task"))
+		recipe, err := jsonld.Import(file)
 		if err != nil {
-			logger.Error("Cannot parse task", slog.String("error", err.Error()))
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			logger.Warn("Cannot import recipe", slog.String("error", err.Error()))
+			http.Error(w, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
 			return
 		}
 
-		cs := internal.NewCookieStorage(recipe, r)
+		// The picture is kept with the uploaded ones, so that it is served
+		// offline and under a hashed URL. The recipe is still imported, with
+		// the default picture, if it cannot be fetched.
+		if recipe.ImageSrc != "" {
+			src, err := imageStore.Fetch(r.Context(), recipe.ImageSrc)
+			if err != nil {
+				logger.Warn("Cannot fetch recipe image", slog.String("src", recipe.ImageSrc), slog.String("error", err.Error()))
+			}
+
+			recipe.ImageSrc = src
+		}
 
-		cookie, err := cs.FinishTask(task)
+		recipe, err = recipes.RegisterNew(recipe)
+		if errors.Is(err, recipes.ErrExists) {
+			logger.Warn("Cannot import recipe", slog.String("error", err.Error()))
+			http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+			return
+		}
 		if err != nil {
-			logger.Error("Cannot parse task", slog.String("error", err.Error()))
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			logger.Warn("Cannot register recipe", slog.String("error", err.Error()))
+			http.Error(w, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
 			return
 		}
 
-		http.SetCookie(w, cookie)
-		r.AddCookie(cookie)
+		// Without a recipe directory the import only lasts until a restart.
+		if cfg.RecipeDir != "" {
+			path, err := recipes.WriteRecipeFile(cfg.RecipeDir, recipe)
+			if err != nil {
+				logger.Error(err.Error())
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			logger.Info("Imported recipe", slog.String("recipe", recipe.Name), slog.String("path", path))
+		}
+
+		http.Redirect(w, r, "/recipe/"+recipe.Name, http.StatusSeeOther)
+	}))
+
+	mux.Handle("GET "+images.URLPrefix+"{image}", imageStore)
+
+	mux.Handle("POST /admin/images", protected(func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
 
-		finished, err := cs.FinishedAllTasks()
+		r.Body = http.MaxBytesReader(w, r.Body, images.MaxUploadSize+1<<20)
+
+		file, _, err := r.FormFile("image")
 		if err != nil {
 			logger.Error(err.Error())
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
+		defer file.Close()
 
-		if !finished {
+		sse := datastar.NewSSE(w, r)
+
+		src, err := imageStore.Save(file)
+		if err != nil {
+			logger.Warn("Cannot save image", slog.String("error", err.Error()))
+			sse.PatchElementTempl(adminview.Errors([]error{err}))
 			return
 		}
 
-		cookie, err = cs.ToNextStep()
+		logger.Info("Saved image", slog.String("src", src))
+
+		sse.PatchElementTempl(adminview.ImageField(src))
+	}))
+
+	mux.Handle("GET /admin/recipes", protected(func(w http.ResponseWriter, r *http.Request) {
+		adminview.Recipes(recipes.ListRecipes()).Render(r.Context(), w)
+	}))
+
+	mux.Handle("GET /admin/recipes/new", protected(func(w http.ResponseWriter, r *http.Request) {
+		adminview.Editor(admin.Draft{}, nil, false).Render(r.Context(), w)
+	}))
+
+	mux.Handle("GET /admin/recipes/{recipe}", protected(func(w http.ResponseWriter, r *http.Request) {
+		recipe, err := recipes.ParseRecipe(r.PathValue("recipe"))
 		if err != nil {
-			logger.Error(err.Error())
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			http.Redirect(w, r, "/admin/recipes", http.StatusSeeOther)
 			return
 		}
 
-		http.SetCookie(w, cookie)
+		adminview.Editor(admin.NewDraft(recipe), nil, true).Render(r.Context(), w)
+	}))
 
-		http.Redirect(w, r, "/recipe/"+recipe.String(), http.StatusSeeOther)
-	})
+	mux.Handle("GET /admin/recipes/{recipe}/history", protected(func(w http.ResponseWriter, r *http.Request) {
+		versions := recipes.ListVersions(r.PathValue("recipe"))
+		if len(versions) == 0 {
+			http.Redirect(w, r, "/admin/recipes", http.StatusSeeOther)
+			return
+		}
 
-	mux.HandleFunc("GET /cook/{recipe}", func(w http.ResponseWriter, r *http.Request) {
-		recipe, err := recipes.ParseRecipe(r.PathValue("recipe"))
+		to := versions[len(versions)-1]
+		from := versions[max(0, len(versions)-2)]
+
+		for _, v := range []struct {
+			param  string
+			recipe *recipes.Recipe
+		}{{"from", &from}, {"to", &to}} {
+			version, err := strconv.Atoi(r.URL.Query().Get(v.param))
+			if err != nil {
+				continue
+			}
+
+			recipe, err := recipes.ParseRecipeVersion(to.Name, version)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+				return
+			}
+			*v.recipe = recipe
+		}
+
+		adminview.History(versions, from, to, recipes.Diff(from, to)).Render(r.Context(), w)
+	}))
+
+	mux.Handle("POST /admin/recipes/preview", protected(func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
+
+		err := r.ParseForm()
 		if err != nil {
-			http.Redirect(w, r, "/", http.StatusSeeOther)
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
 
-		cs := internal.NewCookieStorage(recipe, r)
+		draft := admin.ParseForm(r.Form)
+		errs := draft.Check()
 
-		cookie, err := cs.GetCookingMethodCookie()
+		sse := datastar.NewSSE(w, r)
+		sse.PatchElementTempl(adminview.Errors(errs))
+		for i := range draft.Recipe.Tasks {
+			sse.PatchElementTempl(adminview.Dependencies(draft.Recipe.Tasks, i))
+		}
+		if len(errs) == 0 {
+			sse.PatchElementTempl(adminview.Preview(draft.Recipe, true))
+		}
+	}))
+
+	mux.Handle("POST /admin/recipes/editor", protected(func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
+
+		err := r.ParseForm()
 		if err != nil {
 			logger.Error(err.Error())
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
-		http.SetCookie(w, cookie)
 
-		timeRemaining, err := cs.GetRemainingCookTime()
+		index, err := strconv.Atoi(r.URL.Query().Get("index"))
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		draft := admin.ParseForm(r.Form)
+
+		err = draft.Apply(r.URL.Query().Get("op"), r.URL.Query().Get("list"), index)
+		if err != nil {
+			logger.Warn("Cannot edit recipe", slog.String("error", err.Error()))
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		errs := draft.Check()
+
+		sse := datastar.NewSSE(w, r)
+		sse.PatchElementTempl(adminview.Form(draft))
+		sse.PatchElementTempl(adminview.Errors(errs))
+		if len(errs) == 0 {
+			sse.PatchElementTempl(adminview.Preview(draft.Recipe, true))
+		}
+	}))
+
+	mux.Handle("POST /admin/recipes/save", protected(func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
+
+		err := r.ParseForm()
 		if err != nil {
 			logger.Error(err.Error())
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
 
+		draft := admin.ParseForm(r.Form)
+
 		sse := datastar.NewSSE(w, r)
-		id := "count-down-" + recipe.String()
-		path := fmt.Sprintf("/cook/%s", recipe.String())
 
-		if timeRemaining.Seconds() <= 0 {
-			sse.PatchElementTempl(
-				cooking.Timer(id, path, 0),
-				datastar.WithSelectorID("button-"+recipe.GetCookingMethod().Name),
-				datastar.WithModeAfter(),
-			)
+		errs := draft.Check()
+		if len(errs) > 0 {
+			sse.PatchElementTempl(adminview.Errors(errs))
+			return
+		}
 
-			sse.ExecuteScript(`document.querySelector("#ring").remove()`)
+		recipe, err := recipes.Register(draft.Build())
+		if err != nil {
+			sse.PatchElementTempl(adminview.Errors([]error{err}))
 			return
 		}
 
-		seconds := int(timeRemaining.Seconds())
+		// Without a recipe directory the change only lasts until a restart.
+		if cfg.RecipeDir != "" {
+			path, err := recipes.WriteRecipeFile(cfg.RecipeDir, recipe)
+			if err != nil {
+				logger.Error(err.Error())
+				sse.PatchElementTempl(adminview.Errors([]error{err}))
+				return
+			}
 
-		err = sse.PatchElementTempl(
-			cooking.Timer(id, path, seconds),
-			datastar.WithSelectorID("button-"+recipe.GetCookingMethod().Name),
-			datastar.WithModeAfter(),
-		)
+			logger.Info("Saved recipe", slog.String("recipe", recipe.Name), slog.String("path", path))
+		}
+
+		sse.Redirect("/admin/recipes")
+	}))
+
+	mux.HandleFunc("PATCH /preferences", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
+
+		err := r.ParseForm()
 		if err != nil {
 			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
 
-		ticker := time.NewTicker(1 * time.Second)
-		timer := time.NewTimer(timeRemaining)
-		done := make(chan bool)
+		cs := internal.NewSessionStorage(r, cookieOptions)
 
-		go func() {
-			for {
-				select {
-				case <-done:
-					return
-				case <-ticker.C:
-					seconds--
-
-					err := sse.PatchElementTempl(
-						cooking.Timer(id, path, seconds),
-						datastar.WithModeReplace(),
-					)
-					if err != nil {
-						logger.Error(err.Error())
-						return
-					}
-				}
-			}
-		}()
+		cookie, err := cs.SetPreferences(r.Form)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		cs.SetCookie(w, cookie)
+	})
 
-		<-timer.C
-		ticker.Stop()
-		done <- true
+	mux.HandleFunc("GET /pantry", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
 
-		sse.PatchElementTempl(
-			cooking.Timer(id, path, 0),
-			datastar.WithModeReplace(),
-		)
+		_, stock, err := userPantry(r, cookieOptions, pantryStore)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
 
-		sse.ExecuteScript(`document.querySelector("#ring").remove()`)
-
-		sse.PatchElements(
-			fmt.Sprintf(`
-				<img id="finished-recipe" src="%s"/>
-				<button id="button-%s" disabled>%s</button>
-			`,
-				recipe.GetImageSrc(),
-				recipe.GetCookingMethod().Name,
-				recipe.GetCookingMethod().Name,
-			),
-		)
+		cooking.Pantry(stock).Render(r.Context(), w)
 	})
 
-	mux.HandleFunc("PATCH /cook/{recipe}", func(w http.ResponseWriter, r *http.Request) {
-		recipe, err := recipes.ParseRecipe(r.PathValue("recipe"))
+	mux.HandleFunc("POST /pantry", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
+
+		err := r.ParseForm()
 		if err != nil {
-			http.Redirect(w, r, "/", http.StatusSeeOther)
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		name := strings.TrimSpace(r.FormValue("name"))
+		if !pantry.ValidName(name) {
+			datastar.NewSSE(w, r).PatchElementTempl(cooking.PantryProblem(i18n.T(r.Context(), "pantry.invalid-name")))
 			return
 		}
 
-		cs := internal.NewCookieStorage(recipe, r)
+		// An empty quantity stocks something without measuring it.
+		var quantity *recipes.Quantity
+		if text := strings.TrimSpace(r.FormValue("quantity")); text != "" {
+			q, err := recipes.ParseExactQuantity(text)
+			if err != nil {
+				datastar.NewSSE(w, r).PatchElementTempl(cooking.PantryProblem(err.Error()))
+				return
+			}
+			quantity = &q
+		}
+
+		cookie, _, err := userPantry(r, cookieOptions, pantryStore)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
 
-		cookie, err := cs.DecrementCookingMethodCookie(1 * time.Second)
+		stock, err := pantryStore.Update(cookie.Value, func(p pantry.Pantry) pantry.Pantry {
+			p[name] = quantity
+			return p
+		})
 		if err != nil {
 			logger.Error(err.Error())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
-		http.SetCookie(w, cookie)
+		internal.NewSessionStorage(r, cookieOptions).SetCookie(w, cookie)
+
+		sse := datastar.NewSSE(w, r)
+		sse.PatchElementTempl(cooking.PantryForm())
+		sse.PatchElementTempl(cooking.PantryItems(stock))
 	})
 
-	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
-		cooking.Cooking().Render(r.Context(), w)
+	mux.HandleFunc("DELETE /pantry/{item}", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
+
+		cookie, _, err := userPantry(r, cookieOptions, pantryStore)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		stock, err := pantryStore.Update(cookie.Value, func(p pantry.Pantry) pantry.Pantry {
+			delete(p, r.PathValue("item"))
+			return p
+		})
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		internal.NewSessionStorage(r, cookieOptions).SetCookie(w, cookie)
+
+		datastar.NewSSE(w, r).PatchElementTempl(cooking.PantryItems(stock))
 	})
 
-	server := http.Server{
-		Addr:    fmt.Sprintf(":%d", *port),
-		Handler: mux,
-	}
+	mux.HandleFunc("GET /shopping", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
 
-	logger.Info("Starting server", slog.Int("port", *port))
+		picked, err := shoppingRecipes(r, cookieOptions)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
 
-	if err := server.ListenAndServe(); err != nil {
-		logger.Error("Cannot start server", "error", err.Error())
+		if len(picked) == 0 {
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+
+		list := shopping.New(r.Context(), picked)
+
+		cs := internal.NewSessionStorage(r, cookieOptions)
+
+		selected, cookie, err := cs.SelectShoppingList(list.Recipes)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		cs.SetCookie(w, cookie)
+
+		cooking.Shopping(list, selected.Checked).Render(r.Context(), w)
+	})
+
+	mux.HandleFunc("GET /shopping.txt", func(w http.ResponseWriter, r *http.Request) {
+		picked, err := shoppingRecipes(r, cookieOptions)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, shopping.Text(r.Context(), shopping.New(r.Context(), picked)))
+	})
+
+	mux.HandleFunc("GET /shopping.json", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
+
+		picked, err := shoppingRecipes(r, cookieOptions)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		data, err := json.MarshalIndent(shopping.New(r.Context(), picked), "", "\t")
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+
+	mux.HandleFunc("PATCH /shopping", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context())
+
+		err := r.ParseForm()
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		picked, err := shoppingRecipes(r, cookieOptions)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		list := shopping.New(r.Context(), picked)
+
+		names := []string{}
+		for _, a := range list.Aisles {
+			for _, item := range a.Items {
+				names = append(names, item.Name)
+			}
+		}
+
+		cs := internal.NewSessionStorage(r, cookieOptions)
+
+		cookie, err := cs.CheckShoppingList(names, r.Form)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		cs.SetCookie(w, cookie)
+
+		done := 0
+		for _, name := range names {
+			if r.Form.Has(name) {
+				done++
+			}
+		}
+
+		datastar.NewSSE(w, r).PatchElementTempl(cooking.ShoppingProgress(done, len(names)))
+	})
+
+	mux.HandleFunc("GET /recipe/{recipe}/print", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context()).With(slog.String("recipe", r.PathValue("recipe")))
+
+		recipe, err := pinnedRecipe(r, cookieOptions)
+		if err != nil {
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+
+		recipe = i18n.Localize(r.Context(), recipe)
+
+		tasks, err := recipes.SortTasks(recipe.ListPrepTasks())
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+
+		cooking.Print(recipe, tasks).Render(r.Context(), w)
+	})
+
+	mux.HandleFunc("GET /recipe/{recipe}/pdf", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context()).With(slog.String("recipe", r.PathValue("recipe")))
+
+		recipe, err := pinnedRecipe(r, cookieOptions)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		art, err := readImage(imageStore, recipe.GetImageSrc())
+		if err != nil {
+			logger.Warn("Cannot read recipe image, leaving it out of the PDF", slog.String("error", err.Error()))
+		}
+
+		doc, err := pdf.RecipeCard(r.Context(), recipe, art)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", recipe.String()+".pdf"))
+
+		_, err = doc.WriteTo(w)
+		if err != nil {
+			logger.Error(err.Error())
+		}
+	})
+
+	mux.HandleFunc("PATCH /gather/{recipe}", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context()).With(slog.String("recipe", r.PathValue("recipe")))
+
+		recipe, err := pinnedRecipe(r, cookieOptions)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		err = r.ParseForm()
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		cs := internal.NewCookieStorage(recipe, r, cookieOptions)
+
+		finished, err := gather(w, cs, r.Form)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if !finished {
+			return
+		}
+
+		http.Redirect(w, r, "/recipe/"+recipe.String(), http.StatusSeeOther)
+	})
+
+	mux.HandleFunc("PATCH /prep/{recipe}/{task}", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context()).With(slog.String("recipe", r.PathValue("recipe")))
+
+		recipe, err := pinnedRecipe(r, cookieOptions)
+		if err != nil {
+			logger.Error("Cannot parse recipe", slog.String("error", err.Error()))
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		task, err := recipes.ParseTask(recipe, r.PathValue("task"))
+		if err != nil {
+			logger.Error("Cannot parse task", slog.String("error", err.Error()))
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		cs := internal.NewCookieStorage(recipe, r, cookieOptions)
+
+		finished, err := finishTask(w, r, cs, task)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if finished {
+			http.Redirect(w, r, "/recipe/"+recipe.String(), http.StatusSeeOther)
+			return
+		}
+
+		err = patchTaskProgress(datastar.NewSSE(w, r), r, cookieOptions, cs, recipe, task)
+		if err != nil {
+			logger.Error(err.Error())
+		}
+	})
+
+	mux.HandleFunc("GET /cook/{recipe}", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context()).With(slog.String("recipe", r.PathValue("recipe")))
+
+		recipe, err := pinnedRecipe(r, cookieOptions)
+		if err != nil {
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+
+		cs := internal.NewCookieStorage(recipe, r, cookieOptions)
+
+		cookie, err := cs.GetCookingMethodCookie()
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		cs.SetCookie(w, cookie)
+
+		timeRemaining, err := cs.GetRemainingCookTime()
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		prefs, err := internal.NewSessionStorage(r, cookieOptions).GetPreferences()
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		internal.ActiveCookStreams.Inc(recipe.String())
+		defer internal.ActiveCookStreams.Dec(recipe.String())
+
+		sse := datastar.NewSSE(w, r)
+		id := "count-down-" + recipe.String()
+		path := fmt.Sprintf("/cook/%s", recipe.String())
+		seconds := int(timeRemaining.Seconds())
+
+		// The countdown always follows the cooking method cookie, which is
+		// where the timer really is. When Datastar reconnects a dropped stream
+		// it sends back the ID of the last event it received, the number of
+		// seconds the timer was showing. That only says the timer is already on
+		// the page, so it is replaced rather than inserted again, and whether
+		// the finished event has already been sent.
+		resuming := false
+		alreadyFinished := false
+		if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+			lastSeconds, err := strconv.Atoi(lastEventID)
+			if err != nil {
+				logger.Error("Cannot parse last event ID", slog.String("error", err.Error()))
+			} else {
+				resuming = true
+				alreadyFinished = lastSeconds <= 0
+			}
+		}
+
+		opts := []datastar.PatchElementOption{
+			datastar.WithSelectorID("button-" + recipe.GetCookingMethod().Name),
+			datastar.WithModeAfter(),
+			datastar.WithPatchElementsEventID(strconv.Itoa(max(seconds, 0))),
+		}
+		if resuming {
+			opts = []datastar.PatchElementOption{
+				datastar.WithModeReplace(),
+				datastar.WithPatchElementsEventID(strconv.Itoa(max(seconds, 0))),
+			}
+		}
+
+		// A finished timer is patched below along with the rest of the page.
+		if !resuming || seconds > 0 {
+			err = sse.PatchElementTempl(cooking.Timer(id, path, max(seconds, 0)), opts...)
+			if err != nil {
+				logger.Error(err.Error())
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		status := i18n.DescribeTimeRemaining(r.Context(), seconds)
+		sse.PatchElementTempl(cooking.TimerStatus(status))
+
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+
+		for seconds > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+				seconds--
+
+				if seconds <= 0 {
+					continue
+				}
+
+				err := sse.PatchElementTempl(
+					cooking.Timer(id, path, seconds),
+					datastar.WithModeReplace(),
+					datastar.WithPatchElementsEventID(strconv.Itoa(seconds)),
+				)
+				if err != nil {
+					logger.Error(err.Error())
+					return
+				}
+
+				if next := i18n.DescribeTimeRemaining(r.Context(), seconds); next != status {
+					status = next
+					sse.PatchElementTempl(cooking.TimerStatus(status))
+				}
+			}
+		}
+
+		sse.PatchElementTempl(
+			cooking.Timer(id, path, 0),
+			datastar.WithModeReplace(),
+			datastar.WithPatchElementsEventID("0"),
+		)
+
+		sse.ExecuteScript(`document.querySelector("#ring")?.remove()`)
+
+		sse.PatchElementTempl(cooking.TimerStatus(i18n.DescribeTimeRemaining(r.Context(), 0)))
+
+		sse.PatchElementTempl(cooking.FinishedRecipe(recipe, true))
+		sse.PatchElementTempl(cooking.CookButton(recipe, true))
+
+		// The alarm has already gone off if the stream dropped after the
+		// countdown ended, so don't set it off again on reconnect.
+		if alreadyFinished {
+			return
+		}
+
+		sse.PatchElementTempl(cooking.TimerFinished(recipe, true))
+
+		sse.DispatchCustomEvent(
+			"timer-finished",
+			map[string]any{
+				"title":  i18n.T(r.Context(), "cook.finished.title"),
+				"body":   i18n.T(r.Context(), "cook.finished.body", i18n.Title(r.Context(), recipe)),
+				"icon":   pwa.IconURL("icon-192.png"),
+				"notify": prefs.Notify,
+				"sound":  prefs.Sound,
+			},
+		)
+	})
+
+	mux.HandleFunc("PATCH /cook/{recipe}", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context()).With(slog.String("recipe", r.PathValue("recipe")))
+
+		recipe, err := pinnedRecipe(r, cookieOptions)
+		if err != nil {
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+
+		cs := internal.NewCookieStorage(recipe, r, cookieOptions)
+
+		// Each timer reports the second it is showing, so a repeated report for
+		// the same second (e.g. after a reconnect) does not count down twice.
+		seconds, err := strconv.Atoi(r.URL.Query().Get("seconds"))
+		if err != nil {
+			logger.Error("Cannot parse seconds", slog.String("error", err.Error()))
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		finished, err := cs.FinishedCooking()
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		cookie, err := cs.DecrementCookingMethodCookieTo(time.Duration(seconds-1) * time.Second)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		cs.SetCookie(w, cookie)
+
+		remaining, err := time.ParseDuration(cookie.Value)
+		if err != nil || finished || remaining > 0 {
+			return
+		}
+
+		// The cook has just finished, so what was gathered has been used.
+		pantryCookie, stock, err := userPantry(r, cookieOptions, pantryStore)
+		if err != nil || len(stock) == 0 {
+			if err != nil {
+				logger.Error(err.Error())
+			}
+			return
+		}
+
+		gathered, err := cs.GetGatheredIngredients()
+		if err != nil {
+			logger.Error(err.Error())
+			return
+		}
+
+		// Two tabs can both see the cook finish before either cookie is
+		// updated, so the store records each cook it has taken out.
+		cookCookie, err := cs.GetCookCookie()
+		if err != nil {
+			logger.Error(err.Error())
+			return
+		}
+
+		cook := fmt.Sprintf("%s@%d/%s", recipe.String(), recipe.Version, cookCookie.Value)
+
+		_, err = pantryStore.Use(pantryCookie.Value, cook, recipe, gathered)
+		if err != nil {
+			logger.Error(err.Error())
+		}
+	})
+
+	mux.HandleFunc("POST /voice/{recipe}", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context()).With(slog.String("recipe", r.PathValue("recipe")))
+
+		recipe, err := pinnedRecipe(r, cookieOptions)
+		if err != nil {
+			logger.Error("Cannot parse recipe", slog.String("error", err.Error()))
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		err = r.ParseForm()
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		phrase := r.Form.Get("phrase")
+
+		cs := internal.NewCookieStorage(recipe, r, cookieOptions)
+
+		cookie, err := cs.GetStepCookie()
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		step, err := recipes.ParseRecipeStep(cookie.Value)
+		if err != nil {
+			internal.DecodeErrors.Inc("step")
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		gatheredIngredients, err := cs.GetGatheredIngredients()
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		finishedTasks, err := cs.GetFinishedTasks()
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		command, err := voice.Parse(recipe, phrase)
+		if err != nil {
+			logger.Debug("Cannot parse voice command", slog.String("phrase", phrase))
+			datastar.NewSSE(w, r).PatchElementTempl(cooking.VoiceFeedback(phrase, i18n.T(r.Context(), "voice.not-understood")))
+			return
+		}
+
+		if command.Action == voice.Read || command.Action == voice.ReadNext {
+			narration := voice.Narrate(r.Context(), i18n.Localize(r.Context(), recipe), step, gatheredIngredients, finishedTasks)
+
+			sse := datastar.NewSSE(w, r)
+			sse.PatchElementTempl(cooking.VoiceFeedback(phrase, i18n.T(r.Context(), "voice.ok")))
+			sse.DispatchCustomEvent("narrate", map[string]string{
+				"text": internal.Ternary(command.Action == voice.ReadNext && narration.Next != "", narration.Next, narration.Current),
+			})
+			return
+		}
+
+		timer := voice.TimerNotStarted
+		if cs.StartedCooking() {
+			timer = voice.TimerRunning
+
+			cooked, err := cs.FinishedCooking()
+			if err != nil {
+				logger.Error(err.Error())
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			if cooked {
+				timer = voice.TimerFinished
+			}
+		}
+
+		command, err = command.Resolve(r.Context(), recipe, step, gatheredIngredients, finishedTasks, timer)
+		if err != nil {
+			datastar.NewSSE(w, r).PatchElementTempl(cooking.VoiceFeedback(phrase, err.Error()))
+			return
+		}
+
+		// The command makes the same change as the matching tap would, and
+		// the cookies have to be set before the stream starts.
+		moved := false
+		var task recipes.Task
+
+		switch command.Action {
+		case voice.Gather:
+			form := url.Values{command.Name: {"on"}}
+			for name, gathered := range gatheredIngredients {
+				if gathered {
+					form.Set(name, "on")
+				}
+			}
+
+			moved, err = gather(w, cs, form)
+
+		case voice.FinishTask:
+			task, err = recipes.ParseTask(recipe, command.Name)
+			if err == nil {
+				moved, err = finishTask(w, r, cs, task)
+			}
+		}
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		sse := datastar.NewSSE(w, r)
+		sse.PatchElementTempl(cooking.VoiceFeedback(phrase, i18n.T(r.Context(), "voice.ok")))
+
+		if moved {
+			sse.Redirect("/recipe/" + recipe.String())
+			return
+		}
+
+		switch command.Action {
+		case voice.Gather:
+			sse.MarshalAndPatchSignals(map[string]bool{command.Name: true})
+
+		case voice.FinishTask:
+			sse.MarshalAndPatchSignals(cooking.FinishedTaskSignals(task))
+
+			err = patchTaskProgress(sse, r, cookieOptions, cs, recipe, task)
+			if err != nil {
+				logger.Error(err.Error())
+			}
+
+		case voice.StartTimer:
+			sse.PatchElementTempl(cooking.StartCook(recipe), datastar.WithSelectorID("cook"), datastar.WithModeAppend())
+		}
+	})
+
+	mux.HandleFunc("GET /narrate/{recipe}", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context()).With(slog.String("recipe", r.PathValue("recipe")))
+
+		recipe, err := pinnedRecipe(r, cookieOptions)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		narration, err := narrate(r.Context(), internal.NewCookieStorage(recipe, r, cookieOptions), recipe)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if r.URL.Query().Get("format") == "ssml" || strings.Contains(r.Header.Get("Accept"), "application/ssml+xml") {
+			w.Header().Set("Content-Type", "application/ssml+xml; charset=utf-8")
+			w.Write([]byte(narration.SSML(i18n.Locale(r.Context()))))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(narration.Text()))
+	})
+
+	// POST /narrate/{recipe} has the page read the current item aloud, or the
+	// one after it with ?read=next.
+	mux.HandleFunc("POST /narrate/{recipe}", func(w http.ResponseWriter, r *http.Request) {
+		logger := internal.LoggerFromContext(r.Context()).With(slog.String("recipe", r.PathValue("recipe")))
+
+		recipe, err := pinnedRecipe(r, cookieOptions)
+		if err != nil {
+			logger.Error("Cannot parse recipe", slog.String("error", err.Error()))
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		narration, err := narrate(r.Context(), internal.NewCookieStorage(recipe, r, cookieOptions), recipe)
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		text := narration.Current
+		if r.URL.Query().Get("read") == "next" && narration.Next != "" {
+			text = narration.Next
+		}
+
+		datastar.NewSSE(w, r).DispatchCustomEvent("narrate", map[string]string{"text": text})
+	})
+
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		cooking.Cooking(cfg.AdminPassword != "").Render(r.Context(), w)
+	})
+
+	compressed, err := internal.Compress(mux)
+	if err != nil {
+		logger.Error("Cannot set up compression", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	server := http.Server{
+		Addr:              cfg.Addr,
+		Handler:           internal.LogRequests(logger, i18n.Negotiate(cookieOptions, internal.MeasureRequests(compressed))),
+		Protocols:         internal.Protocols(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if cfg.TLSSelfSigned {
+		created, err := internal.EnsureSelfSignedCert(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			logger.Error("Cannot create self-signed certificate", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+
+		if created {
+			logger.Info("Created self-signed certificate", slog.String("cert", cfg.TLSCert), slog.String("key", cfg.TLSKey))
+		}
+	}
+
+	if cfg.AdminPassword == "" {
+		logger.Warn("No admin password is set, so the recipe editor and imports are disabled; use --admin-password to enable them.")
+	}
+
+	if cfg.TLSCert == "" {
+		logger.Warn("Serving plain HTTP. Cookies are Secure, so browsers only keep them on localhost; use --tls-cert and --tls-key or --tls-self-signed for other hosts.")
+		logger.Info("Starting server", slog.String("addr", cfg.Addr))
+
+		if err := server.ListenAndServe(); err != nil {
+			logger.Error("Cannot start server", "error", err.Error())
+		}
+
+		return
+	}
+
+	server.TLSConfig, err = internal.TLSConfig(cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		logger.Error("Cannot load certificate", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	logger.Info("Starting server", slog.String("addr", cfg.Addr), slog.Bool("tls", true))
+
+	if err := server.ListenAndServeTLS("", ""); err != nil {
+		logger.Error("Cannot start server", "error", err.Error())
+	}
+}
+
+func writeJSONLD(w http.ResponseWriter, r *http.Request, v any) {
+	logger := internal.LoggerFromContext(r.Context())
+
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		logger.Error(err.Error())
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", jsonld.ContentType)
+	w.Write(data)
+}
+
+// pinnedRecipe is the version of the requested recipe that the session
+// started cooking, or the latest for a session that has not started.
+func pinnedRecipe(r *http.Request, opts internal.CookieOptions) (recipes.Recipe, error) {
+	latest, err := recipes.ParseRecipe(r.PathValue("recipe"))
+	if err != nil {
+		return recipes.Recipe{}, err
+	}
+
+	cookie, err := internal.NewCookieStorage(latest, r, opts).GetVersionCookie()
+	if err != nil {
+		return recipes.Recipe{}, err
+	}
+
+	version, err := strconv.Atoi(cookie.Value)
+	if err != nil {
+		internal.DecodeErrors.Inc("version")
+		return latest, nil
+	}
+
+	pinned, err := recipes.ParseRecipeVersion(latest.Name, version)
+	if err != nil {
+		// The version is gone, e.g. the server ran without a recipe
+		// directory and has restarted.
+		return latest, nil
+	}
+
+	return pinned, nil
+}
+
+// shoppingRecipes are the recipes picked for a shopping list in the query,
+// or else the ones picked last time. A recipe that has since gone is left out
+// of the last list.
+func shoppingRecipes(r *http.Request, opts internal.CookieOptions) ([]recipes.Recipe, error) {
+	names := r.URL.Query()["recipe"]
+	fromQuery := len(names) > 0
+
+	if !fromQuery {
+		list, err := internal.NewSessionStorage(r, opts).GetShoppingList()
+		if err != nil {
+			return nil, err
+		}
+		names = list.Recipes
+	}
+
+	picked := []recipes.Recipe{}
+	for _, name := range names {
+		recipe, err := recipes.ParseRecipe(name)
+		if err != nil {
+			if fromQuery {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+
+		if !slices.ContainsFunc(picked, func(p recipes.Recipe) bool { return p.Name == recipe.Name }) {
+			picked = append(picked, recipe)
+		}
+	}
+
+	return picked, nil
+}
+
+// gather records the checked ingredients and reports whether that finished
+// gathering, in which case the recipe has moved on to prep.
+func gather(w http.ResponseWriter, cs internal.CookieStorage, form url.Values) (bool, error) {
+	cookie, err := cs.GatherIngredients(form)
+	if err != nil {
+		return false, err
+	}
+	cs.SetCookie(w, cookie)
+
+	finished, err := cs.FinishedGatheringIngredients(cookie)
+	if err != nil || !finished {
+		return false, err
+	}
+
+	cookie, err = cs.ToNextStep()
+	if err != nil {
+		return false, err
+	}
+	cs.SetCookie(w, cookie)
+
+	return true, nil
+}
+
+// finishTask records a finished prep task and reports whether it was the
+// last, in which case the recipe has moved on to cooking.
+func finishTask(w http.ResponseWriter, r *http.Request, cs internal.CookieStorage, task recipes.Task) (bool, error) {
+	cookie, err := cs.FinishTask(task)
+	if err != nil {
+		return false, err
+	}
+	cs.SetCookie(w, cookie)
+	r.AddCookie(cs.Sign(cookie))
+
+	finished, err := cs.FinishedAllTasks()
+	if err != nil || !finished {
+		return false, err
+	}
+
+	cookie, err = cs.ToNextStep()
+	if err != nil {
+		return false, err
+	}
+	cs.SetCookie(w, cookie)
+
+	return true, nil
+}
+
+// patchTaskProgress redraws the task graph and announces the next task.
+// Finishing the last one redirects to the cook step instead, which
+// announces itself.
+func patchTaskProgress(sse *datastar.ServerSentEventGenerator, r *http.Request, opts internal.CookieOptions, cs internal.CookieStorage, recipe recipes.Recipe, task recipes.Task) error {
+	finishedTasks, err := cs.GetFinishedTasks()
+	if err != nil {
+		return err
+	}
+	finishedTasks[task.Name] = true
+
+	prefs, err := internal.NewSessionStorage(r, opts).GetPreferences()
+	if err != nil {
+		return err
+	}
+
+	err = sse.PatchElementTempl(cooking.TaskGraph(i18n.Localize(r.Context(), recipe), finishedTasks))
+	if err != nil || !prefs.Narrate {
+		return err
+	}
+
+	narration, err := narrate(r.Context(), cs, recipe)
+	if err != nil {
+		return err
+	}
+
+	return sse.DispatchCustomEvent("narrate", map[string]string{"text": narration.Current})
+}
+
+// userPantry reads the pantry that the request's cookie names. A user without
+// one gets a new, empty pantry, whose cookie is set once something is stocked.
+func userPantry(r *http.Request, opts internal.CookieOptions, store *pantry.Store) (*http.Cookie, pantry.Pantry, error) {
+	cookie, err := internal.NewSessionStorage(r, opts).GetPantryCookie()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A cookie that is not a pantry ID, e.g. one that has been tampered
+	// with, is replaced like a missing one rather than failing every page.
+	p, err := store.Get(cookie.Value)
+	if errors.Is(err, pantry.ErrID) {
+		internal.DecodeErrors.Inc("pantry")
+		cookie.Value = pantry.NewID()
+		p, err = pantry.Pantry{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cookie, p, nil
+}
+
+// narrate reads the cook's position in the recipe from their cookies.
+func narrate(ctx context.Context, cs internal.CookieStorage, recipe recipes.Recipe) (voice.Narration, error) {
+	cookie, err := cs.GetStepCookie()
+	if err != nil {
+		return voice.Narration{}, err
+	}
+
+	step, err := recipes.ParseRecipeStep(cookie.Value)
+	if err != nil {
+		internal.DecodeErrors.Inc("step")
+		return voice.Narration{}, err
+	}
+
+	gatheredIngredients, err := cs.GetGatheredIngredients()
+	if err != nil {
+		return voice.Narration{}, err
+	}
+
+	finishedTasks, err := cs.GetFinishedTasks()
+	if err != nil {
+		return voice.Narration{}, err
 	}
+
+	return voice.Narrate(ctx, i18n.Localize(ctx, recipe), step, gatheredIngredients, finishedTasks), nil
+}
+
+// readImage decodes a recipe's ImageSrc, either an uploaded image or an
+// embedded static one.
+func readImage(store *images.Store, src string) (image.Image, error) {
+	var f io.ReadCloser
+	var err error
+	if strings.HasPrefix(src, images.URLPrefix) {
+		f, err = store.Open(src)
+	} else {
+		f, err = Files.Open(strings.TrimPrefix(src, "/"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+
+	return img, nil
+}
+
+func checkRecipes() error {
+	list := recipes.ListRecipes()
+	if len(list) == 0 {
+		return errors.New("no recipes loaded")
+	}
+
+	for _, r := range list {
+		err := r.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,115 @@
+// Package pantry keeps track of what a cook has in stock, so that opening a
+// recipe can check off what is already at hand and finishing one can take
+// away what it used.
+package pantry
+
+import (
+	"cooking-with-datastar/cmd/recipes"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+)
+
+// Pantry maps an ingredient name to how much of it is in stock. A nil
+// quantity is kept in stock without being measured, like salt, and is never
+// used up.
+type Pantry map[string]*recipes.Quantity
+
+// The statuses of a recipe's ingredient against the pantry.
+const (
+	InStock = "in-stock"
+	Short   = "short"
+	Missing = "missing"
+)
+
+// namePattern matches ingredient names, which are used in URLs and element
+// IDs on the pantry page.
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// ValidName reports whether name can be stocked.
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// Names lists what is in stock in order.
+func (p Pantry) Names() []string {
+	return slices.Sorted(maps.Keys(p))
+}
+
+// Status is in-stock when the pantry has enough of the ingredient, short when
+// it has some but less than the recipe uses and missing otherwise. An amount
+// that cannot be compared, such as pounds of butter for a recipe that uses
+// cups, or an ingredient without an amount counts as in stock.
+func (p Pantry) Status(i recipes.Ingredient) string {
+	stock, ok := p[i.Name]
+	if !ok {
+		return Missing
+	}
+
+	if stock == nil {
+		return InStock
+	}
+
+	need, ok := i.GetQuantity()
+	if !ok {
+		return InStock
+	}
+
+	need, ok = need.Convert(stock.Unit)
+	if !ok || hundredths(stock.Amount-need.Amount) >= 0 {
+		return InStock
+	}
+
+	return Short
+}
+
+// Covered is whether each of the recipe's ingredients is in stock, in the
+// form of the gathered ingredients.
+func (p Pantry) Covered(r recipes.Recipe) map[string]bool {
+	covered := map[string]bool{}
+	for _, i := range r.ListIngredients() {
+		covered[i.Name] = p.Status(i) == InStock
+	}
+
+	return covered
+}
+
+// Use takes the gathered ingredients that were measured out of the pantry.
+// Anything used up is removed.
+func (p Pantry) Use(r recipes.Recipe, gathered map[string]bool) Pantry {
+	used := maps.Clone(p)
+
+	for _, i := range r.ListIngredients() {
+		stock, ok := used[i.Name]
+		if !gathered[i.Name] || !ok || stock == nil {
+			continue
+		}
+
+		need, ok := i.GetQuantity()
+		if !ok {
+			continue
+		}
+
+		need, ok = need.Convert(stock.Unit)
+		if !ok {
+			continue
+		}
+
+		left := recipes.Quantity{Amount: stock.Amount - need.Amount, Unit: stock.Unit}
+		if hundredths(left.Amount) <= 0 {
+			delete(used, i.Name)
+			continue
+		}
+
+		used[i.Name] = &left
+	}
+
+	return used
+}
+
+// hundredths rounds an amount to the precision it is shown in, since
+// converting units leaves rounding errors.
+func hundredths(amount float64) float64 {
+	return math.Round(amount * 100)
+}
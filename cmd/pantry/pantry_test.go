@@ -0,0 +1,180 @@
+package pantry_test
+
+import (
+	"cooking-with-datastar/cmd/pantry"
+	"cooking-with-datastar/cmd/recipes"
+	"errors"
+	"maps"
+	"testing"
+)
+
+var toast = recipes.Recipe{
+	Name: "toast",
+	Ingredients: []recipes.Ingredient{
+		{Name: "bread", Description: "2 slices of bread"},
+		{Name: "butter", Description: "1 tablespoon butter"},
+		{Name: "jam", Description: "Jam to taste"},
+		{Name: "salt", Description: "0.25 teaspoon salt"},
+	},
+}
+
+func quantity(amount float64, unit string) *recipes.Quantity {
+	return &recipes.Quantity{Amount: amount, Unit: unit}
+}
+
+func TestStatus(t *testing.T) {
+	p := pantry.Pantry{
+		"bread":  quantity(1, "slice"),
+		"butter": quantity(1, "cup"),
+		"jam":    quantity(1, "can"),
+		"salt":   nil,
+	}
+
+	expected := map[string]string{
+		"bread":  pantry.Short,
+		"butter": pantry.InStock,
+		"jam":    pantry.InStock,
+		"salt":   pantry.InStock,
+	}
+
+	for _, i := range toast.Ingredients {
+		got := p.Status(i)
+		if got != expected[i.Name] {
+			t.Logf("%s: want '%s', got '%s'", i.Name, expected[i.Name], got)
+			t.Fail()
+		}
+	}
+
+	got := pantry.Pantry{}.Status(toast.Ingredients[0])
+	if got != pantry.Missing {
+		t.Logf("want '%s', got '%s'", pantry.Missing, got)
+		t.Fail()
+	}
+}
+
+func TestUse(t *testing.T) {
+	p := pantry.Pantry{
+		"bread":  quantity(2, "slice"),
+		"butter": quantity(0.5, "cup"),
+		"salt":   nil,
+	}
+	gathered := map[string]bool{"bread": true, "butter": true, "jam": true, "salt": true}
+
+	used := p.Use(toast, gathered)
+
+	if _, ok := used["bread"]; ok {
+		t.Logf("want the bread used up, got '%v'", used["bread"])
+		t.Fail()
+	}
+
+	if got := used["butter"].String(); got != "0.44 cups" {
+		t.Logf("want '0.44 cups', got '%s'", got)
+		t.Fail()
+	}
+
+	if salt, ok := used["salt"]; !ok || salt != nil {
+		t.Logf("want unmeasured salt kept, got '%v' %v", salt, ok)
+		t.Fail()
+	}
+
+	if p["butter"].Amount != 0.5 {
+		t.Logf("want the original pantry unchanged, got '%v'", p["butter"])
+		t.Fail()
+	}
+
+	notGathered := p.Use(toast, map[string]bool{"bread": true})
+	if notGathered["butter"].Amount != 0.5 {
+		t.Logf("want butter that was not gathered kept, got '%v'", notGathered["butter"])
+		t.Fail()
+	}
+}
+
+func TestStore(t *testing.T) {
+	store, err := pantry.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := pantry.NewID()
+
+	p, err := store.Get(id)
+	if err != nil || len(p) != 0 {
+		t.Fatalf("want an empty pantry, got %v, %v", p, err)
+	}
+
+	_, err = store.Update(id, func(p pantry.Pantry) pantry.Pantry {
+		p["butter"] = quantity(1, "cup")
+		p["salt"] = nil
+		return p
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = store.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := pantry.Pantry{"butter": quantity(1, "cup"), "salt": nil}
+	equal := maps.EqualFunc(p, expected, func(a, b *recipes.Quantity) bool {
+		return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+	})
+	if !equal {
+		t.Logf("want '%v', got '%v'", expected, p)
+		t.Fail()
+	}
+
+	_, err = store.Get("../../etc/passwd")
+	if !errors.Is(err, pantry.ErrID) {
+		t.Logf("want '%v', got '%v'", pantry.ErrID, err)
+		t.Fail()
+	}
+}
+
+func TestStoreUseOnce(t *testing.T) {
+	store, err := pantry.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := pantry.NewID()
+
+	_, err = store.Update(id, func(p pantry.Pantry) pantry.Pantry {
+		p["butter"] = quantity(1, "cup")
+		return p
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gathered := map[string]bool{"butter": true}
+
+	for i, want := range []bool{true, false} {
+		used, err := store.Use(id, "toast@1/a", toast, gathered)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if used != want {
+			t.Logf("use %d: want %v, got %v", i+1, want, used)
+			t.Fail()
+		}
+	}
+
+	p, err := store.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p["butter"] == nil || p["butter"].Amount != 0.9375 {
+		t.Logf("want 0.9375 cups of butter left, got %v", p["butter"])
+		t.Fail()
+	}
+
+	used, err := store.Use(id, "toast@1/b", toast, gathered)
+	if err != nil || !used {
+		t.Logf("want another cook to be used, got %v, %v", used, err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,171 @@
+package pantry
+
+import (
+	"cooking-with-datastar/cmd/recipes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+var ErrID = errors.New("pantry: not a pantry ID")
+
+var idPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// NewID returns a random pantry ID. It is the only thing that identifies a
+// pantry, so it is long enough not to be guessed.
+func NewID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// Store keeps each pantry as a JSON file named after its ID.
+type Store struct {
+	dir string
+	// mu serializes updates, so that two finished cooks do not both use
+	// the same stock.
+	mu sync.Mutex
+}
+
+// NewStore keeps pantries in dir, creating it if needed.
+func NewStore(dir string) (*Store, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{dir: dir}, nil
+}
+
+// record is what is saved for a pantry.
+type record struct {
+	Items Pantry `json:"items"`
+	// Cooked holds the latest cooks taken out of the pantry, so that a cook
+	// reported finished more than once, e.g. from two tabs, is only taken
+	// out once.
+	Cooked []string `json:"cooked,omitempty"`
+}
+
+// maxCooked bounds the cooks remembered per pantry. Repeated reports of a
+// cook arrive within moments of each other, so only the latest matter.
+const maxCooked = 50
+
+// Get returns the pantry with the ID, which is empty until something is put
+// in it.
+func (s *Store) Get(id string) (Pantry, error) {
+	rec, err := s.read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return rec.Items, nil
+}
+
+// Update changes the pantry with the ID and returns it as saved.
+func (s *Store) Update(id string, change func(Pantry) Pantry) (Pantry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rec.Items = change(rec.Items)
+
+	err = s.write(id, rec)
+	if err != nil {
+		return nil, err
+	}
+
+	return rec.Items, nil
+}
+
+// Use takes what a cook used out of the pantry with the ID, unless that cook
+// has already been taken out. It reports whether the pantry changed.
+func (s *Store) Use(id string, cook string, r recipes.Recipe, gathered map[string]bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.read(id)
+	if err != nil {
+		return false, err
+	}
+
+	if slices.Contains(rec.Cooked, cook) {
+		return false, nil
+	}
+
+	rec.Items = rec.Items.Use(r, gathered)
+	rec.Cooked = append(rec.Cooked, cook)
+	if len(rec.Cooked) > maxCooked {
+		rec.Cooked = rec.Cooked[len(rec.Cooked)-maxCooked:]
+	}
+
+	err = s.write(id, rec)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *Store) read(id string) (record, error) {
+	if !idPattern.MatchString(id) {
+		return record{}, ErrID
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return record{Items: Pantry{}}, nil
+	}
+	if err != nil {
+		return record{}, err
+	}
+
+	rec := record{}
+	err = json.Unmarshal(data, &rec)
+	if err != nil {
+		return record{}, err
+	}
+
+	if rec.Items == nil {
+		rec.Items = Pantry{}
+	}
+
+	return rec, nil
+}
+
+func (s *Store) write(id string, rec record) error {
+	data, err := json.MarshalIndent(rec, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	// Write then rename, so that a request never sees half a file.
+	tmp, err := os.CreateTemp(s.dir, ".pantry-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, id+".json"))
+}
@@ -0,0 +1,55 @@
+package pdf
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/i18n"
+	"cooking-with-datastar/cmd/internal"
+	"cooking-with-datastar/cmd/recipes"
+	"fmt"
+	"image"
+)
+
+// RecipeCard lays a recipe out on Letter paper in the same order as the
+// printable page: ingredients, tasks in an order that respects their
+// dependencies, then the cooking method. art may be nil.
+func RecipeCard(ctx context.Context, r recipes.Recipe, art image.Image) (*Document, error) {
+	r = i18n.Localize(ctx, r)
+	title := i18n.Title(ctx, r)
+
+	tasks, err := recipes.SortTasks(r.ListPrepTasks())
+	if err != nil {
+		return nil, err
+	}
+
+	d := New(LetterWidth, LetterHeight, 54)
+	d.SetTitle(title)
+
+	d.Text(Bold, 22, 0, "", title)
+	d.Space(12)
+
+	if art != nil {
+		d.Image(art, 0, 120)
+		d.Space(12)
+	}
+
+	d.Text(Bold, 14, 0, "", i18n.T(ctx, "print.ingredients"))
+	for _, group := range r.ListIngredientGroups() {
+		d.Text(Regular, 11, 14, "•", i18n.DescribeGroup(ctx, group))
+	}
+
+	d.Space(12)
+	d.Text(Bold, 14, 0, "", i18n.T(ctx, "step.prepare"))
+	for i, task := range tasks {
+		d.Text(Regular, 11, 20, fmt.Sprintf("%d.", i+1), task.Description)
+		d.Space(3)
+	}
+
+	cm := r.GetCookingMethod()
+
+	d.Space(12)
+	d.Text(Bold, 14, 0, "", i18n.Label(ctx, r, cm.Name))
+	d.Text(Regular, 11, 0, "", cm.Description)
+	d.Text(Regular, 11, 0, "", i18n.T(ctx, "print.cook-time", internal.DisplayMinutesSeconds(int(cm.CookTime.Seconds()))))
+
+	return d, nil
+}
@@ -0,0 +1,243 @@
+// Package pdf writes simple text-and-image documents without any
+// dependencies. It uses the standard Helvetica fonts, which every PDF reader
+// has, so no fonts need to be embedded.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+)
+
+// Page sizes in points.
+const (
+	LetterWidth  = 612
+	LetterHeight = 792
+)
+
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+var fontNames = map[Font]string{
+	Regular: "Helvetica",
+	Bold:    "Helvetica-Bold",
+}
+
+type pdfImage struct {
+	width  int
+	height int
+	data   []byte
+}
+
+// Document lays content out top to bottom, starting a new page whenever the
+// next line would run into the bottom margin.
+type Document struct {
+	width  float64
+	height float64
+	margin float64
+
+	pages  []*bytes.Buffer
+	images []pdfImage
+	title  string
+
+	// y is the baseline of the next line, measured from the bottom of the page.
+	y float64
+}
+
+func New(width float64, height float64, margin float64) *Document {
+	d := &Document{
+		width:  width,
+		height: height,
+		margin: margin,
+	}
+
+	d.AddPage()
+
+	return d
+}
+
+// SetTitle sets the title shown by PDF readers in place of the file name.
+func (d *Document) SetTitle(title string) {
+	d.title = title
+}
+
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = d.height - d.margin
+}
+
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// ContentWidth is the width between the left and right margins.
+func (d *Document) ContentWidth() float64 {
+	return d.width - 2*d.margin
+}
+
+// Space moves down by the given number of points.
+func (d *Document) Space(points float64) {
+	d.y -= points
+}
+
+// Text writes a paragraph, wrapped to the content width less the indent. The
+// prefix, e.g. a bullet or a step number, is written in the indent of the
+// first line.
+func (d *Document) Text(font Font, size float64, indent float64, prefix string, text string) {
+	leading := size * 1.35
+	lines := wrap(font, size, text, d.ContentWidth()-indent)
+
+	for i, line := range lines {
+		if d.y-leading < d.margin {
+			d.AddPage()
+		}
+
+		d.y -= leading
+
+		if i == 0 && prefix != "" {
+			d.writeText(font, size, d.margin, d.y, prefix)
+		}
+
+		d.writeText(font, size, d.margin+indent, d.y, line)
+	}
+}
+
+func (d *Document) writeText(font Font, size float64, x float64, y float64, text string) {
+	fmt.Fprintf(d.page(), "BT /F%d %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font+1, size, x, y, escape(encode(text)))
+}
+
+// Image draws an image at the given width, keeping its aspect ratio, with
+// its top left corner at the current position offset by x, and moves below it.
+func (d *Document) Image(img image.Image, x float64, width float64) {
+	b := img.Bounds()
+	height := width * float64(b.Dy()) / float64(b.Dx())
+
+	if d.y-height < d.margin {
+		d.AddPage()
+	}
+
+	// Anything beyond 144 dpi is wasted on paper, so large images are scaled
+	// down, nearest neighbour, which also keeps pixel art crisp.
+	w, h := b.Dx(), b.Dy()
+	if limit := int(width * 2); w > limit {
+		w, h = limit, max(1, h*limit/w)
+	}
+
+	rgb := make([]byte, 0, w*h*3)
+	for py := range h {
+		for px := range w {
+			r, g, bl, a := img.At(b.Min.X+px*b.Dx()/w, b.Min.Y+py*b.Dy()/h).RGBA()
+
+			// Transparent pixels are drawn against white paper.
+			white := 0xffff - a
+			rgb = append(rgb, byte((r+white)>>8), byte((g+white)>>8), byte((bl+white)>>8))
+		}
+	}
+
+	d.images = append(d.images, pdfImage{w, h, rgb})
+
+	fmt.Fprintf(d.page(), "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", width, height, d.margin+x, d.y-height, len(d.images))
+
+	d.y -= height
+}
+
+// WriteTo writes the finished document.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	offsets := []int{}
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	stream := func(dict string, data []byte) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n<< %s /Length %d >>\nstream\n", len(offsets), dict, len(data))
+		out.Write(data)
+		out.WriteString("\nendstream\nendobj\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Fixed objects: 1 catalog, 2 page tree, 3 info, 4 and 5 fonts. Images
+	// follow, then a page and its contents for each page.
+	firstImage := 6
+	firstPage := firstImage + len(d.images)
+
+	kids := []string{}
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object(fmt.Sprintf("<< /Title (%s) /Producer (cooking-with-datastar) >>", escape(encode(d.title))))
+
+	for _, f := range []Font{Regular, Bold} {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", fontNames[f]))
+	}
+
+	xobjects := []string{}
+	for i, img := range d.images {
+		data, err := deflate(img.data)
+		if err != nil {
+			return 0, err
+		}
+
+		stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode", img.width, img.height), data)
+		xobjects = append(xobjects, fmt.Sprintf("/Im%d %d 0 R", i+1, firstImage+i))
+	}
+
+	resources := fmt.Sprintf("<< /Font << /F1 4 0 R /F2 5 0 R >> /XObject << %s >> >>", strings.Join(xobjects, " "))
+
+	for i, p := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R >>", d.width, d.height, resources, firstPage+2*i+1))
+
+		data, err := deflate(p.Bytes())
+		if err != nil {
+			return 0, err
+		}
+
+		stream("/Filter /FlateDecode", data)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.WriteTo(w)
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	zw := zlib.NewWriter(&buf)
+
+	_, err := zw.Write(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = zw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// escape makes text safe to write inside a PDF string literal.
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", "", "\n", " ").Replace(s)
+}
@@ -0,0 +1,87 @@
+package pdf_test
+
+import (
+	"bytes"
+	"context"
+	"cooking-with-datastar/cmd/pdf"
+	"cooking-with-datastar/cmd/recipes"
+	"fmt"
+	"image"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		font     pdf.Font
+		text     string
+		expected float64
+	}{
+		{"empty", pdf.Regular, "", 0},
+		{"ascii", pdf.Regular, "Hi", 10 * (722 + 222) / 1000.0},
+		{"latin-1", pdf.Regular, "é", 10 * 556 / 1000.0},
+		{"bold is wider", pdf.Bold, "Hi", 10 * (722 + 222) / 1000.0 * 1.08},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := pdf.Width(tc.font, 10, tc.text)
+
+			if fmt.Sprintf("%.3f", result) != fmt.Sprintf("%.3f", tc.expected) {
+				t.Logf("want '%.3f', got '%.3f'", tc.expected, result)
+				t.Fail()
+			}
+		})
+	}
+}
+
+// TestRecipeCard checks that the cross-reference table points at every
+// object, which is what PDF readers use to find them.
+func TestRecipeCard(t *testing.T) {
+	doc, err := pdf.RecipeCard(context.Background(), recipes.BuffaloChickenDip, image.NewRGBA(image.Rect(0, 0, 640, 480)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	_, err = doc.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) {
+		t.Fatal("want a PDF header")
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("want a startxref trailer")
+	}
+
+	xref, _ := strconv.Atoi(string(m[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+
+	if len(entries) == 0 {
+		t.Fatal("want cross-reference entries")
+	}
+
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(string(e[1]))
+		expected := fmt.Sprintf("%d 0 obj", i+1)
+
+		if !bytes.HasPrefix(data[offset:], []byte(expected)) {
+			t.Logf("want '%s' at %d", expected, offset)
+			t.Fail()
+		}
+	}
+
+	// The image is scaled down to 144 dpi at 120pt wide.
+	if !bytes.Contains(data, []byte("/Width 240 /Height 180")) {
+		t.Log("want the image scaled down")
+		t.Fail()
+	}
+}
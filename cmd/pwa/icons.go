@@ -0,0 +1,37 @@
+package pwa
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// RenderIcon scales src into a size x size square. The recipe art is pixel
+// art, so nearest-neighbour scaling keeps the edges crisp. padding is the
+// fraction of the icon left around the art on each side, which maskable
+// icons need so that the art survives being cropped to a circle. The padding
+// is filled with the colour of the art's top left pixel.
+func RenderIcon(src image.Image, size int, padding float64) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	b := src.Bounds()
+	background := color.RGBAModel.Convert(src.At(b.Min.X, b.Min.Y))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+
+	inner := size - 2*int(float64(size)*padding)
+	scale := float64(inner) / float64(max(b.Dx(), b.Dy()))
+	w := int(float64(b.Dx()) * scale)
+	h := int(float64(b.Dy()) * scale)
+	offsetX := (size - w) / 2
+	offsetY := (size - h) / 2
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx := b.Min.X + int(float64(x)/scale)
+			sy := b.Min.Y + int(float64(y)/scale)
+			dst.Set(offsetX+x, offsetY+y, src.At(sx, sy))
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,50 @@
+package pwa_test
+
+import (
+	"cooking-with-datastar/cmd/pwa"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestRenderIcon(t *testing.T) {
+	background := color.RGBA{240, 215, 170, 255}
+	art := color.RGBA{208, 92, 28, 255}
+
+	// A 4x2 image: a background column, then art.
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		src.Set(0, y, background)
+		for x := 1; x < 4; x++ {
+			src.Set(x, y, art)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		padding  float64
+		x, y     int
+		expected color.RGBA
+	}{
+		{"art is scaled", 0, 60, 40, art},
+		{"letterboxed with the background", 0, 60, 5, background},
+		{"padding uses the background", 0.25, 90, 50, background},
+		{"art inside padding", 0.25, 70, 50, art},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			icon := pwa.RenderIcon(src, 100, tc.padding)
+
+			if icon.Bounds().Dx() != 100 || icon.Bounds().Dy() != 100 {
+				t.Fatalf("want a 100x100 icon, got %v", icon.Bounds())
+			}
+
+			result := icon.RGBAAt(tc.x, tc.y)
+			if result != tc.expected {
+				t.Logf("want %v at (%d, %d), got %v", tc.expected, tc.x, tc.y, result)
+				t.Fail()
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package pwa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+const (
+	Name        = "Cooking with Datastar"
+	ShortName   = "Cooking"
+	ThemeColor  = "#d05c1c"
+	Background  = "#f0d7aa"
+	ManifestURL = "/manifest.webmanifest"
+	WorkerURL   = "/sw.js"
+)
+
+type Icon struct {
+	Name    string
+	Size    int
+	Padding float64
+	Purpose string
+}
+
+var Icons = []Icon{
+	{"icon-192.png", 192, 0, "any"},
+	{"icon-512.png", 512, 0, "any"},
+	{"maskable-512.png", 512, 0.1, "maskable"},
+}
+
+func IconURL(name string) string {
+	return "/icons/" + name
+}
+
+type manifestIcon struct {
+	Src     string `json:"src"`
+	Sizes   string `json:"sizes"`
+	Type    string `json:"type"`
+	Purpose string `json:"purpose"`
+}
+
+func ManifestHandler() http.Handler {
+	icons := []manifestIcon{}
+	for _, i := range Icons {
+		icons = append(icons, manifestIcon{IconURL(i.Name), fmt.Sprintf("%dx%d", i.Size, i.Size), "image/png", i.Purpose})
+	}
+
+	manifest, _ := json.Marshal(map[string]any{
+		"name":             Name,
+		"short_name":       ShortName,
+		"start_url":        "/",
+		"scope":            "/",
+		"display":          "standalone",
+		"theme_color":      ThemeColor,
+		"background_color": Background,
+		"icons":            icons,
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/manifest+json")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(manifest)
+	})
+}
+
+// IconHandler renders every icon from the recipe art at src once, up front,
+// and serves them from /icons/{icon}.
+func IconHandler(fsys fs.FS, src string) (http.Handler, error) {
+	f, err := fsys.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	art, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+
+	rendered := map[string][]byte{}
+	for _, i := range Icons {
+		var buf bytes.Buffer
+
+		err := png.Encode(&buf, RenderIcon(art, i.Size, i.Padding))
+		if err != nil {
+			return nil, err
+		}
+
+		rendered[i.Name] = buf.Bytes()
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		icon, ok := rendered[r.PathValue("icon")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		w.Write(icon)
+	}), nil
+}
+
+//go:embed sw.js
+var workerSource string
+
+var workerTemplate = template.Must(template.New("sw.js").Parse(workerSource))
+
+// ServiceWorkerHandler serves the worker that precaches the given URLs. It
+// is served from the root rather than /static/ so that its scope covers the
+// whole app.
+func ServiceWorkerHandler(precache []string, buildRevision string) (http.Handler, error) {
+	list, err := json.Marshal(precache)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(buildRevision + "\n" + strings.Join(precache, "\n")))
+
+	var buf bytes.Buffer
+	err = workerTemplate.Execute(&buf, map[string]string{
+		"Version":  hex.EncodeToString(sum[:6]),
+		"Precache": string(list),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	worker := buf.Bytes()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Service-Worker-Allowed", "/")
+		w.Write(worker)
+	}), nil
+}
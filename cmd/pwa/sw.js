@@ -0,0 +1,212 @@
+// Rendered by pwa.ServiceWorkerHandler. The cache name changes whenever any
+// precached URL does, which drops the old cache on activation.
+const CACHE = "cooking-{{ .Version }}";
+const PRECACHE = {{ .Precache }};
+const QUEUE_DB = "cooking-with-datastar";
+const QUEUE_STORE = "patches";
+const SYNC_TAG = "replay-patches";
+
+self.addEventListener("install", (event) => {
+	event.waitUntil(
+		caches
+			.open(CACHE)
+			.then((cache) => cache.addAll(PRECACHE))
+			.then(() => self.skipWaiting()),
+	);
+});
+
+self.addEventListener("activate", (event) => {
+	event.waitUntil(
+		(async () => {
+			for (const key of await caches.keys()) {
+				if (key !== CACHE) {
+					await caches.delete(key);
+				}
+			}
+
+			await self.clients.claim();
+			await replayQueue();
+		})(),
+	);
+});
+
+self.addEventListener("fetch", (event) => {
+	const request = event.request;
+	const url = new URL(request.url);
+	const sameOrigin = url.origin === self.location.origin;
+
+	// Checking off ingredients and finishing tasks keep working offline: the
+	// request is queued and replayed, in order, once the connection is back.
+	if (request.method === "PATCH" && sameOrigin && /^\/(gather|prep)\//.test(url.pathname)) {
+		event.respondWith(patchOrQueue(request));
+		return;
+	}
+
+	if (request.method !== "GET") {
+		return;
+	}
+
+	// Static files and uploaded images are served under content-hashed URLs
+	// and the CDN assets are pinned, so none of them can go stale.
+	if (!sameOrigin || url.pathname.startsWith("/static/") || url.pathname.startsWith("/images/") || url.pathname.startsWith("/icons/")) {
+		event.respondWith(cacheFirst(request));
+		return;
+	}
+
+	if (url.pathname === "/" || url.pathname.startsWith("/recipe/")) {
+		event.respondWith(networkFirst(request));
+	}
+});
+
+self.addEventListener("sync", (event) => {
+	if (event.tag === SYNC_TAG) {
+		event.waitUntil(
+			replayQueue().then((replayed) => {
+				if (!replayed) {
+					throw new Error("Still offline");
+				}
+			}),
+		);
+	}
+});
+
+self.addEventListener("message", (event) => {
+	if (event.data?.type === "replay") {
+		event.waitUntil(replayQueue());
+	}
+});
+
+async function cacheFirst(request) {
+	const cached = await caches.match(request);
+	if (cached) {
+		return cached;
+	}
+
+	const response = await fetch(request);
+	if (response.ok || response.type === "opaque") {
+		const cache = await caches.open(CACHE);
+		await cache.put(request, response.clone());
+	}
+
+	return response;
+}
+
+async function networkFirst(request) {
+	try {
+		const response = await fetch(request);
+		if (response.ok) {
+			const cache = await caches.open(CACHE);
+			await cache.put(request, response.clone());
+		}
+
+		return response;
+	} catch (err) {
+		const cached = await caches.match(request);
+		if (cached) {
+			return cached;
+		}
+
+		throw err;
+	}
+}
+
+async function patchOrQueue(request) {
+	const entry = {
+		url: request.url,
+		method: request.method,
+		contentType: request.headers.get("Content-Type"),
+		body: await request.clone().text(),
+	};
+
+	// Anything already queued has to reach the server first, otherwise an
+	// older ingredient list could overwrite a newer one.
+	if (await replayQueue()) {
+		try {
+			return await fetch(request);
+		} catch {
+			// Fall through and queue it.
+		}
+	}
+
+	await withStore("readwrite", (store) => store.add(entry));
+	await self.registration.sync?.register(SYNC_TAG).catch(() => {});
+
+	return new Response(null, { status: 202, headers: { "X-Queued": "true" } });
+}
+
+let replaying = null;
+
+// replayQueue sends queued requests in the order they were made and resolves
+// to false if the server still cannot be reached.
+function replayQueue() {
+	replaying ??= (async () => {
+		let redirectedTo = null;
+
+		try {
+			const entries = await withStore("readonly", (store) => store.getAll());
+			const keys = await withStore("readonly", (store) => store.getAllKeys());
+
+			for (let i = 0; i < entries.length; i++) {
+				const entry = entries[i];
+				const response = await fetch(entry.url, {
+					method: entry.method,
+					headers: entry.contentType ? { "Content-Type": entry.contentType } : {},
+					body: entry.body,
+					credentials: "same-origin",
+				});
+
+				await withStore("readwrite", (store) => store.delete(keys[i]));
+
+				// Finishing a step answers with a redirect to the recipe rather
+				// than with Datastar's event stream.
+				if (response.redirected) {
+					redirectedTo = response.url;
+				}
+			}
+		} catch {
+			return false;
+		} finally {
+			replaying = null;
+		}
+
+		// A replayed request that finished a step redirects to the recipe,
+		// so tell the page to load the next step.
+		if (redirectedTo) {
+			for (const client of await self.clients.matchAll()) {
+				client.postMessage({ type: "replayed", url: redirectedTo });
+			}
+		}
+
+		return true;
+	})();
+
+	return replaying;
+}
+
+function withStore(mode, fn) {
+	return new Promise((resolve, reject) => {
+		const open = indexedDB.open(QUEUE_DB, 1);
+
+		open.onupgradeneeded = () => {
+			open.result.createObjectStore(QUEUE_STORE, { autoIncrement: true });
+		};
+
+		open.onerror = () => reject(open.error);
+
+		open.onsuccess = () => {
+			const db = open.result;
+			const tx = db.transaction(QUEUE_STORE, mode);
+			const request = fn(tx.objectStore(QUEUE_STORE));
+
+			tx.oncomplete = () => {
+				db.close();
+				resolve(request.result);
+			};
+
+			tx.onerror = () => {
+				db.close();
+				reject(tx.error);
+			};
+		};
+	});
+}
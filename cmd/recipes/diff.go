@@ -0,0 +1,131 @@
+package recipes
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// The kinds of Change.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// The parts of a recipe a Change can be in.
+const (
+	IngredientSection    = "ingredient"
+	TaskSection          = "task"
+	DependencySection    = "dependencies"
+	CookingMethodSection = "cooking-method"
+	CookTimeSection      = "cook-time"
+)
+
+// Change is one difference between two versions of a recipe. Before is empty
+// for an addition and After for a removal.
+type Change struct {
+	Kind    string
+	Section string
+	Name    string
+	Before  string
+	After   string
+}
+
+// Diff lists the ingredient, task and cooking method changes from one version
+// of a recipe to another, matching items by name. Removals come first, then
+// additions and changes in the newer version's order.
+func Diff(from Recipe, to Recipe) []Change {
+	changes := []Change{}
+
+	oldIngredients := map[string]Ingredient{}
+	for _, i := range from.Ingredients {
+		oldIngredients[i.Name] = i
+	}
+
+	newIngredients := map[string]Ingredient{}
+	for _, i := range to.Ingredients {
+		newIngredients[i.Name] = i
+	}
+
+	for _, i := range from.Ingredients {
+		if _, ok := newIngredients[i.Name]; !ok {
+			changes = append(changes, Change{Removed, IngredientSection, i.Name, describe(i), ""})
+		}
+	}
+
+	for _, i := range to.Ingredients {
+		old, ok := oldIngredients[i.Name]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Added, IngredientSection, i.Name, "", describe(i)})
+
+		case describe(old) != describe(i):
+			changes = append(changes, Change{Changed, IngredientSection, i.Name, describe(old), describe(i)})
+		}
+	}
+
+	oldTasks := map[string]Task{}
+	for _, t := range from.Tasks {
+		oldTasks[t.Name] = t
+	}
+
+	newTasks := map[string]Task{}
+	for _, t := range to.Tasks {
+		newTasks[t.Name] = t
+	}
+
+	for _, t := range from.Tasks {
+		if _, ok := newTasks[t.Name]; !ok {
+			changes = append(changes, Change{Removed, TaskSection, t.Name, t.Description, ""})
+		}
+	}
+
+	for _, t := range to.Tasks {
+		old, ok := oldTasks[t.Name]
+		if !ok {
+			changes = append(changes, Change{Added, TaskSection, t.Name, "", t.Description})
+			continue
+		}
+
+		if old.Description != t.Description {
+			changes = append(changes, Change{Changed, TaskSection, t.Name, old.Description, t.Description})
+		}
+
+		if !slices.Equal(slices.Sorted(slices.Values(old.Dependencies)), slices.Sorted(slices.Values(t.Dependencies))) {
+			changes = append(changes, Change{Changed, DependencySection, t.Name, strings.Join(old.Dependencies, ", "), strings.Join(t.Dependencies, ", ")})
+		}
+	}
+
+	oldMethod, newMethod := from.CookingMethod, to.CookingMethod
+	if oldMethod.Name != newMethod.Name || oldMethod.Description != newMethod.Description {
+		changes = append(changes, Change{Changed, CookingMethodSection, newMethod.Name, oldMethod.Name + ": " + oldMethod.Description, newMethod.Name + ": " + newMethod.Description})
+	}
+
+	if oldMethod.CookTime != newMethod.CookTime {
+		changes = append(changes, Change{Changed, CookTimeSection, newMethod.Name, oldMethod.CookTime.String(), newMethod.CookTime.String()})
+	}
+
+	return changes
+}
+
+// describe adds the ingredient's quantity, when it is not in the
+// description, whether it is optional or substitutable and its aisle to its
+// description, so that changing any of them shows up.
+func describe(i Ingredient) string {
+	text := i.Description
+	if i.Quantity != nil {
+		text += fmt.Sprintf(" [%s]", i.Quantity)
+	}
+	if i.Optional {
+		text += " (optional)"
+	}
+	if i.Group != "" {
+		text += fmt.Sprintf(" (or another %s)", i.Group)
+	}
+	if i.Aisle != "" {
+		text += fmt.Sprintf(" (%s aisle)", i.Aisle)
+	}
+
+	return text
+}
@@ -0,0 +1,64 @@
+package recipes_test
+
+import (
+	"cooking-with-datastar/cmd/recipes"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	toast := recipes.Recipe{
+		Name:        "toast",
+		Ingredients: []recipes.Ingredient{{Name: "bread", Description: "2 slices of bread"}, {Name: "butter", Description: "1 tablespoon butter"}},
+		Tasks: []recipes.Task{
+			{"slice", "Slice the bread", []string{}},
+			{"spread", "Spread the butter", []string{"slice"}},
+		},
+		CookingMethod: recipes.CookingMethod{Name: "toast", Description: "Toast until golden", CookTime: 90 * time.Second},
+	}
+
+	tests := []struct {
+		name     string
+		change   func(r *recipes.Recipe)
+		expected []recipes.Change
+	}{
+		{"same", func(r *recipes.Recipe) {}, []recipes.Change{}},
+		{"ingredient added", func(r *recipes.Recipe) {
+			r.Ingredients = append(r.Ingredients, recipes.Ingredient{Name: "jam", Description: "1 tablespoon jam"})
+		}, []recipes.Change{{recipes.Added, recipes.IngredientSection, "jam", "", "1 tablespoon jam"}}},
+		{"ingredient removed", func(r *recipes.Recipe) {
+			r.Ingredients = r.Ingredients[:1]
+		}, []recipes.Change{{recipes.Removed, recipes.IngredientSection, "butter", "1 tablespoon butter", ""}}},
+		{"ingredient made optional", func(r *recipes.Recipe) {
+			r.Ingredients[1].Optional = true
+		}, []recipes.Change{{recipes.Changed, recipes.IngredientSection, "butter", "1 tablespoon butter", "1 tablespoon butter (optional)"}}},
+		{"quantity given", func(r *recipes.Recipe) {
+			r.Ingredients[0].Quantity = &recipes.Quantity{Amount: 2, Unit: "slice"}
+		}, []recipes.Change{{recipes.Changed, recipes.IngredientSection, "bread", "2 slices of bread", "2 slices of bread [2 slices]"}}},
+		{"task changed", func(r *recipes.Recipe) {
+			r.Tasks[0].Description = "Cut the bread"
+		}, []recipes.Change{{recipes.Changed, recipes.TaskSection, "slice", "Slice the bread", "Cut the bread"}}},
+		{"dependencies changed", func(r *recipes.Recipe) {
+			r.Tasks[1].Dependencies = []string{}
+		}, []recipes.Change{{recipes.Changed, recipes.DependencySection, "spread", "slice", ""}}},
+		{"cook time changed", func(r *recipes.Recipe) {
+			r.CookingMethod.CookTime = 2 * time.Minute
+		}, []recipes.Change{{recipes.Changed, recipes.CookTimeSection, "toast", "1m30s", "2m0s"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to := toast
+			to.Ingredients = slices.Clone(toast.Ingredients)
+			to.Tasks = slices.Clone(toast.Tasks)
+			tt.change(&to)
+
+			got := recipes.Diff(toast, to)
+			if !slices.Equal(got, tt.expected) {
+				t.Logf("want '%v', got '%v'", tt.expected, got)
+				t.Fail()
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package recipes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Quantity is an amount of an ingredient in a unit, such as 0.5 cup. Things
+// that are counted, like eggs, have no unit.
+type Quantity struct {
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit,omitempty"`
+}
+
+type unit struct {
+	// dimension is what the unit measures. Units convert only within one.
+	dimension string
+	// size is the unit in the dimension's base: milliliters, grams, or one
+	// of a counted unit.
+	size float64
+}
+
+// cup is a US cup in milliliters. Spoons are defined from it, so that 16
+// tablespoons make exactly a cup.
+const cup = 236.588
+
+var units = map[string]unit{
+	"teaspoon":   {"volume", cup / 48},
+	"tablespoon": {"volume", cup / 16},
+	"cup":        {"volume", cup},
+	"milliliter": {"volume", 1},
+	"liter":      {"volume", 1000},
+	"ounce":      {"weight", 28.3495},
+	"pound":      {"weight", 453.592},
+	"gram":       {"weight", 1},
+	"kilogram":   {"weight", 1000},
+	"":           {"count", 1},
+	"can":        {"can", 1},
+	"clove":      {"clove", 1},
+	"slice":      {"slice", 1},
+	"package":    {"package", 1},
+}
+
+var unitAliases = map[string]string{
+	"teaspoons":   "teaspoon",
+	"tsp":         "teaspoon",
+	"tablespoons": "tablespoon",
+	"tbsp":        "tablespoon",
+	"cups":        "cup",
+	"c":           "cup",
+	"milliliters": "milliliter",
+	"ml":          "milliliter",
+	"liters":      "liter",
+	"l":           "liter",
+	"ounces":      "ounce",
+	"oz":          "ounce",
+	"pounds":      "pound",
+	"lb":          "pound",
+	"lbs":         "pound",
+	"grams":       "gram",
+	"g":           "gram",
+	"kilograms":   "kilogram",
+	"kg":          "kilogram",
+	"cans":        "can",
+	"cloves":      "clove",
+	"slices":      "slice",
+	"packages":    "package",
+}
+
+// ParseUnit reads a unit as it is written in a recipe, e.g. "cups" or "tbsp".
+// The empty string is the unit of counted things.
+func ParseUnit(text string) (string, error) {
+	text = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(text), "."))
+
+	if alias, ok := unitAliases[text]; ok {
+		return alias, nil
+	}
+
+	if _, ok := units[text]; ok {
+		return text, nil
+	}
+
+	return "", fmt.Errorf("unknown unit %q", text)
+}
+
+// ParseQuantity reads the amount at the start of an ingredient description,
+// such as "1.5 cups mozzarella cheese" or "1 1/2 cups flour". Descriptions
+// like "Hot sauce to taste" have none.
+func ParseQuantity(text string) (Quantity, bool) {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return Quantity{}, false
+	}
+
+	amount, ok := parseAmount(words[0])
+	if !ok {
+		return Quantity{}, false
+	}
+	words = words[1:]
+
+	// A mixed number, as in "1 1/2 cups".
+	if len(words) > 0 && strings.Contains(words[0], "/") {
+		if fraction, ok := parseAmount(words[0]); ok {
+			amount += fraction
+			words = words[1:]
+		}
+	}
+
+	if len(words) > 0 {
+		u, err := ParseUnit(strings.TrimRightFunc(words[0], unicode.IsPunct))
+		if err == nil {
+			return Quantity{amount, u}, true
+		}
+	}
+
+	return Quantity{amount, ""}, true
+}
+
+// ParseExactQuantity reads an amount and an optional unit, such as "2",
+// "1/2 cup" or "1 1/2 tbsp". Unlike ParseQuantity it rejects anything else,
+// for fields that hold only a quantity.
+func ParseExactQuantity(text string) (Quantity, error) {
+	q, ok := ParseQuantity(text)
+	if !ok {
+		return Quantity{}, fmt.Errorf("quantity %q must start with an amount, e.g. 1/2 cup", text)
+	}
+
+	words := strings.Fields(text)
+	last := words[len(words)-1]
+
+	_, isAmount := ParseQuantity(last)
+	_, err := ParseUnit(last)
+	if len(words) > 1 && !isAmount && err != nil {
+		return Quantity{}, fmt.Errorf("quantity %q: %w", text, err)
+	}
+
+	if len(words) > 3 {
+		return Quantity{}, fmt.Errorf("quantity %q must be only an amount and a unit, e.g. 1/2 cup", text)
+	}
+
+	return q, nil
+}
+
+func parseAmount(word string) (float64, bool) {
+	if n, d, ok := strings.Cut(word, "/"); ok {
+		numerator, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0, false
+		}
+
+		denominator, err := strconv.ParseFloat(d, 64)
+		if err != nil || denominator == 0 {
+			return 0, false
+		}
+
+		return numerator / denominator, true
+	}
+
+	amount, err := strconv.ParseFloat(word, 64)
+	if err != nil || amount < 0 || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return 0, false
+	}
+
+	return amount, true
+}
+
+// Convert expresses q in another unit of the same dimension.
+func (q Quantity) Convert(to string) (Quantity, bool) {
+	from, ok := units[q.Unit]
+	if !ok {
+		return Quantity{}, false
+	}
+
+	target, ok := units[to]
+	if !ok || target.dimension != from.dimension {
+		return Quantity{}, false
+	}
+
+	return Quantity{q.Amount * from.size / target.size, to}, true
+}
+
+// Add sums two quantities in q's unit, if they can be converted.
+func (q Quantity) Add(other Quantity) (Quantity, bool) {
+	converted, ok := other.Convert(q.Unit)
+	if !ok {
+		return Quantity{}, false
+	}
+
+	return Quantity{q.Amount + converted.Amount, q.Unit}, true
+}
+
+// String writes the amount to two decimal places at most, with the unit in
+// the plural when it is not one.
+func (q Quantity) String() string {
+	amount := strconv.FormatFloat(math.Round(q.Amount*100)/100, 'f', -1, 64)
+	if q.Unit == "" {
+		return amount
+	}
+
+	if amount == "1" {
+		return amount + " " + q.Unit
+	}
+
+	return amount + " " + q.Unit + "s"
+}
+
+// GetQuantity is the ingredient's Quantity if it has one, or else the amount
+// its description starts with.
+func (i Ingredient) GetQuantity() (Quantity, bool) {
+	if i.Quantity != nil {
+		return *i.Quantity, true
+	}
+
+	return ParseQuantity(i.Description)
+}
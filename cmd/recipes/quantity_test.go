@@ -0,0 +1,103 @@
+package recipes_test
+
+import (
+	"cooking-with-datastar/cmd/recipes"
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected recipes.Quantity
+		ok       bool
+	}{
+		{"1.5 cups mozzarella cheese", recipes.Quantity{Amount: 1.5, Unit: "cup"}, true},
+		{"1 teaspoon garlic powder", recipes.Quantity{Amount: 1, Unit: "teaspoon"}, true},
+		{"8 ounces cream cheese", recipes.Quantity{Amount: 8, Unit: "ounce"}, true},
+		{"2 large eggs", recipes.Quantity{Amount: 2}, true},
+		{"1/2 cup ketchup", recipes.Quantity{Amount: 0.5, Unit: "cup"}, true},
+		{"1 1/2 tbsp. honey", recipes.Quantity{Amount: 1.5, Unit: "tablespoon"}, true},
+		{"3 lb, boneless", recipes.Quantity{Amount: 3, Unit: "pound"}, true},
+		{"Hot sauce to taste", recipes.Quantity{}, false},
+		{"", recipes.Quantity{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, ok := recipes.ParseQuantity(tt.text)
+			if ok != tt.ok || got != tt.expected {
+				t.Logf("want '%v' %v, got '%v' %v", tt.expected, tt.ok, got, ok)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestParseExactQuantity(t *testing.T) {
+	tests := []struct {
+		text  string
+		valid bool
+	}{
+		{"2", true},
+		{"1/2 cup", true},
+		{"1 1/2 tbsp", true},
+		{"2 large eggs", false},
+		{"2 loaves", false},
+		{"some", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			_, err := recipes.ParseExactQuantity(tt.text)
+			if (err == nil) != tt.valid {
+				t.Logf("want valid %v, got error '%v'", tt.valid, err)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestQuantityAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     recipes.Quantity
+		expected string
+		ok       bool
+	}{
+		{"same unit", recipes.Quantity{Amount: 1, Unit: "cup"}, recipes.Quantity{Amount: 0.5, Unit: "cup"}, "1.5 cups", true},
+		{"volumes", recipes.Quantity{Amount: 1, Unit: "cup"}, recipes.Quantity{Amount: 4, Unit: "tablespoon"}, "1.25 cups", true},
+		{"weights", recipes.Quantity{Amount: 1, Unit: "pound"}, recipes.Quantity{Amount: 8, Unit: "ounce"}, "1.5 pounds", true},
+		{"counts", recipes.Quantity{Amount: 2}, recipes.Quantity{Amount: 1}, "3", true},
+		{"volume and weight", recipes.Quantity{Amount: 1, Unit: "cup"}, recipes.Quantity{Amount: 1, Unit: "pound"}, "", false},
+		{"count and volume", recipes.Quantity{Amount: 2}, recipes.Quantity{Amount: 1, Unit: "cup"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.a.Add(tt.b)
+			if ok != tt.ok || (ok && got.String() != tt.expected) {
+				t.Logf("want '%s' %v, got '%s' %v", tt.expected, tt.ok, got, ok)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestGetQuantity(t *testing.T) {
+	explicit := recipes.Ingredient{Name: "salt", Description: "A pinch of salt", Quantity: &recipes.Quantity{Amount: 0.125, Unit: "teaspoon"}}
+
+	got, ok := explicit.GetQuantity()
+	if !ok || got != *explicit.Quantity {
+		t.Logf("want '%v', got '%v'", *explicit.Quantity, got)
+		t.Fail()
+	}
+
+	parsed := recipes.Ingredient{Name: "flour", Description: "3 cups all-purpose flour"}
+
+	got, ok = parsed.GetQuantity()
+	if !ok || got.String() != "3 cups" {
+		t.Logf("want '3 cups', got '%v'", got)
+		t.Fail()
+	}
+}
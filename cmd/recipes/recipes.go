@@ -1,19 +1,35 @@
 package recipes
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
 	"time"
 )
 
 type Ingredient struct {
-	Name        string
-	Description string
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Optional ingredients, like nuts or a garnish, can be left out.
+	Optional bool `json:"optional,omitempty"`
+	// Group names a set of ingredients that substitute for one another, such
+	// as two dressings: gathering any one of them is enough.
+	Group string `json:"group,omitempty"`
+	// Quantity is how much of the ingredient is used, for when it cannot be
+	// read from the start of the description.
+	Quantity *Quantity `json:"quantity,omitempty"`
+	// Aisle is where the ingredient is found in a store, one of Aisles.
+	Aisle string `json:"aisle,omitempty"`
 }
 
+// Aisles lists store aisles in the order a shopping list walks them.
+var Aisles = []string{"produce", "meat", "dairy", "baking", "spices", "condiments", "canned", "frozen", "other"}
+
 type Task struct {
-	Name         string
-	Description  string
-	Dependencies []string
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Dependencies []string `json:"dependencies"`
 }
 
 type CookingMethod struct {
@@ -22,152 +38,214 @@ type CookingMethod struct {
 	CookTime    time.Duration
 }
 
-type Recipe int
-
-const (
-	BuffaloChickenDip Recipe = iota
-	ChocolateChipCookies
-	PulledPork
-)
-
-var recipeName = map[Recipe]string{
-	BuffaloChickenDip:    "buffalo-chicken-dip",
-	ChocolateChipCookies: "chocolate-chip-cookies",
-	PulledPork:           "pulled-pork",
+type cookingMethodJSON struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CookTime    string `json:"cookTime"`
 }
 
-func (r Recipe) String() string {
-	return recipeName[r]
+// MarshalJSON writes the cook time as a Go duration string such as "1h30m"
+// so that recipe files stay readable.
+func (cm CookingMethod) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cookingMethodJSON{cm.Name, cm.Description, cm.CookTime.String()})
 }
 
-func (r Recipe) ListIngredients() []Ingredient {
-	switch r {
-	case BuffaloChickenDip:
-		return []Ingredient{
-			{"chicken", "3 large boneless skinless chicken breasts"},
-			{"cream-cheese", "8 ounces cream cheese"},
-			{"ranch-dressing", "1 cup ranch dressing"},
-			{"hot-sauce", "1 cup hot sauce"},
-			{"black-pepper", "1 teaspoon freshly ground black pepper"},
-			{"garlic-powder", "1 teaspoon garlic powder"},
-			{"green-onion", "0.5 cup green onion"},
-			{"mozzarella-cheese", "1.5 cups mozzarella cheese"},
-			{"cheddar-cheese", "1.5 cups cheddar cheese"},
-		}
+func (cm *CookingMethod) UnmarshalJSON(data []byte) error {
+	var v cookingMethodJSON
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
 
-	case ChocolateChipCookies:
-		return []Ingredient{
-			{"butter", "1 cup butter, softened"},
-			{"white-sugar", "1 cup white sugar"},
-			{"brow-sugar", "1 cup packed brown sugar"},
-			{"eggs", "2 large eggs"},
-			{"vanilla", "2 teaspoons vanilla extract"},
-			{"baking-soda", "1 teaspoon baking soda"},
-			{"hot-water", "2 teaspoons hot water"},
-			{"salt", "0.5 teaspoon salt"},
-			{"flour", "3 cups all-purpose flour"},
-			{"chocolate-chips", "2 cups semisweet chocolate chips"},
-			{"walnuts", "1 cup chopped walnuts"},
-		}
+	cookTime, err := time.ParseDuration(v.CookTime)
+	if err != nil {
+		return fmt.Errorf("cookTime: %w", err)
+	}
 
-	case PulledPork:
-		return []Ingredient{
-			{"pork-shoulder", "3 pound boneless pork shoulder roast"},
-			{"ketchup", "1 cup ketchup"},
-			{"brown-sugar", "0.5 cup firmly packed brown sugar"},
-			{"vinegar", "0.25 cup apple cider vinegar"},
-			{"hot-sauce", "Hot sauce to taste"},
-		}
+	*cm = CookingMethod{v.Name, v.Description, cookTime}
 
-	default:
-		return []Ingredient{}
-	}
+	return nil
 }
 
-func (r Recipe) ListPrepTasks() []Task {
-	switch r {
-	case BuffaloChickenDip:
-		return []Task{
-			{"cook-the-chicken", "Poach the chicken for approximately 25 minutes. When fully cooked, remove from pot and allow to cool until safe to handle.", []string{}},
-			{"shred", "Shred chicken in food processor.", []string{"cook-the-chicken"}},
-			{"heat-the-oven", "Preheat the oven to 350 degrees farenheit.", []string{}},
-			{"cube", "Cut the cream cheese into 1 inch cubes.", []string{}},
-			{"warm-the-sauce", "Heat medium sauce pot over medium-low heat. Add the cubed cream cheese, ranch dressing, hot sauce, black pepper, and garlic powder. Whisk constantly until the cream cheese has dissolved. Remove from heat.", []string{"cube"}},
-			{"prep-the-pan", "Apply cooking spray to 9x9 inch pan.", []string{}},
-			{"combine", "Combine the shredded chicken, sauce, green onions, and cheese in a large pot. Transfer to baking pan.", []string{"cook-the-chicken", "shred", "heat-the-oven", "cube", "warm-the-sauce", "prep-the-pan"}},
-		}
+type Recipe struct {
+	Name          string        `json:"name"`
+	ImageSrc      string        `json:"imageSrc"`
+	Ingredients   []Ingredient  `json:"ingredients"`
+	Tasks         []Task        `json:"tasks"`
+	CookingMethod CookingMethod `json:"cookingMethod"`
+	// Translations are keyed by locale, e.g. "es".
+	Translations map[string]Translation `json:"translations,omitempty"`
+	// Version counts the saves of a recipe, from 1. It is set by Register.
+	Version int `json:"version,omitempty"`
+}
 
-	case ChocolateChipCookies:
-		return []Task{
-			{"heat-the-oven", "Preheat the oven to 350 degrees farenheit.", []string{}},
-			{"beat-eggs", "Beat in eggs, one at a time, then stir in vanilla.", []string{}},
-			{"add-baking-soda", "Dissolve baking soda in hot water. Add to batter along with salt.", []string{"beat-eggs"}},
-			{"stir-in-flour", "Stir in flour, chocolate chips, and walnuts.", []string{"add-baking-soda"}},
-			{"place-dough", "Drop spoonfuls of dough 2 inches apart onto ungreased baking sheets.", []string{"stir-in-flour"}},
-		}
+var BuffaloChickenDip = Recipe{
+	Name:     "buffalo-chicken-dip",
+	ImageSrc: "/static/buffalo_chicken_dip_pixel_art_small.png",
+	Ingredients: []Ingredient{
+		{Name: "chicken", Description: "3 large boneless skinless chicken breasts", Aisle: "meat"},
+		{Name: "cream-cheese", Description: "8 ounces cream cheese", Aisle: "dairy"},
+		{Name: "ranch-dressing", Description: "1 cup ranch dressing", Group: "dressing", Aisle: "condiments"},
+		{Name: "blue-cheese-dressing", Description: "1 cup blue cheese dressing", Group: "dressing", Aisle: "condiments"},
+		{Name: "hot-sauce", Description: "1 cup hot sauce", Aisle: "condiments"},
+		{Name: "black-pepper", Description: "1 teaspoon freshly ground black pepper", Aisle: "spices"},
+		{Name: "garlic-powder", Description: "1 teaspoon garlic powder", Aisle: "spices"},
+		{Name: "green-onion", Description: "0.5 cup green onion", Aisle: "produce"},
+		{Name: "mozzarella-cheese", Description: "1.5 cups mozzarella cheese", Aisle: "dairy"},
+		{Name: "cheddar-cheese", Description: "1.5 cups cheddar cheese", Aisle: "dairy"},
+	},
+	Tasks: []Task{
+		{"cook-the-chicken", "Poach the chicken for approximately 25 minutes. When fully cooked, remove from pot and allow to cool until safe to handle.", []string{}},
+		{"shred", "Shred chicken in food processor.", []string{"cook-the-chicken"}},
+		{"heat-the-oven", "Preheat the oven to 350 degrees farenheit.", []string{}},
+		{"cube", "Cut the cream cheese into 1 inch cubes.", []string{}},
+		{"warm-the-sauce", "Heat medium sauce pot over medium-low heat. Add the cubed cream cheese, dressing, hot sauce, black pepper, and garlic powder. Whisk constantly until the cream cheese has dissolved. Remove from heat.", []string{"cube"}},
+		{"prep-the-pan", "Apply cooking spray to 9x9 inch pan.", []string{}},
+		{"combine", "Combine the shredded chicken, sauce, green onions, and cheese in a large pot. Transfer to baking pan.", []string{"cook-the-chicken", "shred", "heat-the-oven", "cube", "warm-the-sauce", "prep-the-pan"}},
+	},
+	CookingMethod: CookingMethod{"bake", "Bake for 20-30 minutes, or until the cheese has melted and the sides are starting to bubble.", 10 * time.Second},
+	Translations:  map[string]Translation{"es": buffaloChickenDipES},
+	Version:       1,
+}
 
-	case PulledPork:
-		return []Task{
-			{"place", "Place pork roast in a slow cooker.", []string{}},
-			{"combine", "Whisk ketchup, brown sugar, vinegar, and hot sauce together in a bowl until well combined", []string{}},
-			{"pour", "Pour the mixture over the pork. Turn pork to coat completely.", []string{"place", "combine"}},
-		}
+var ChocolateChipCookies = Recipe{
+	Name:     "chocolate-chip-cookies",
+	ImageSrc: "/static/chocolate_chip_cookies_small.png",
+	Ingredients: []Ingredient{
+		{Name: "butter", Description: "1 cup butter, softened", Aisle: "dairy"},
+		{Name: "white-sugar", Description: "1 cup white sugar", Aisle: "baking"},
+		{Name: "brown-sugar", Description: "1 cup packed brown sugar", Aisle: "baking"},
+		{Name: "eggs", Description: "2 large eggs", Aisle: "dairy"},
+		{Name: "vanilla", Description: "2 teaspoons vanilla extract", Aisle: "baking"},
+		{Name: "baking-soda", Description: "1 teaspoon baking soda", Aisle: "baking"},
+		{Name: "hot-water", Description: "2 teaspoons hot water"},
+		{Name: "salt", Description: "0.5 teaspoon salt", Aisle: "spices"},
+		{Name: "flour", Description: "3 cups all-purpose flour", Aisle: "baking"},
+		{Name: "chocolate-chips", Description: "2 cups semisweet chocolate chips", Aisle: "baking"},
+		{Name: "walnuts", Description: "1 cup chopped walnuts", Optional: true, Aisle: "baking"},
+	},
+	Tasks: []Task{
+		{"heat-the-oven", "Preheat the oven to 350 degrees farenheit.", []string{}},
+		{"beat-eggs", "Beat in eggs, one at a time, then stir in vanilla.", []string{}},
+		{"add-baking-soda", "Dissolve baking soda in hot water. Add to batter along with salt.", []string{"beat-eggs"}},
+		{"stir-in-flour", "Stir in flour, chocolate chips, and walnuts.", []string{"add-baking-soda"}},
+		{"place-dough", "Drop spoonfuls of dough 2 inches apart onto ungreased baking sheets.", []string{"stir-in-flour"}},
+	},
+	CookingMethod: CookingMethod{"bake", "Bake for 10-12 minutes", 5 * time.Second},
+	Translations:  map[string]Translation{"es": chocolateChipCookiesES},
+	Version:       1,
+}
 
-	default:
-		return []Task{}
-	}
+var PulledPork = Recipe{
+	Name:     "pulled-pork",
+	ImageSrc: "/static/hamburger_small.png",
+	Ingredients: []Ingredient{
+		{Name: "pork-shoulder", Description: "3 pound boneless pork shoulder roast", Aisle: "meat"},
+		{Name: "ketchup", Description: "1 cup ketchup", Aisle: "condiments"},
+		{Name: "brown-sugar", Description: "0.5 cup firmly packed brown sugar", Aisle: "baking"},
+		{Name: "vinegar", Description: "0.25 cup apple cider vinegar", Aisle: "condiments"},
+		{Name: "hot-sauce", Description: "Hot sauce to taste", Optional: true, Aisle: "condiments"},
+	},
+	Tasks: []Task{
+		{"place", "Place pork roast in a slow cooker.", []string{}},
+		{"combine", "Whisk ketchup, brown sugar, vinegar, and hot sauce together in a bowl until well combined", []string{}},
+		{"pour", "Pour the mixture over the pork. Turn pork to coat completely.", []string{"place", "combine"}},
+	},
+	CookingMethod: CookingMethod{"slow-cook", "Slow cook on low for 8 to 10 hours or High for 4 to 6 hours.", 15 * time.Second},
+	Translations:  map[string]Translation{"es": pulledPorkES},
+	Version:       1,
 }
 
-func (r Recipe) GetCookingMethod() CookingMethod {
-	switch r {
-	case BuffaloChickenDip:
-		return CookingMethod{"bake", "Bake for 20-30 minutes, or until the cheese has melted and the sides are starting to bubble.", 10 * time.Second}
+func (r Recipe) String() string {
+	return r.Name
+}
 
-	case ChocolateChipCookies:
-		return CookingMethod{"bake", "Bake for 10-12 minutes", 5 * time.Second}
+func (r Recipe) ListIngredients() []Ingredient {
+	return r.Ingredients
+}
 
-	case PulledPork:
-		return CookingMethod{"slow-cook", "Slow cook on low for 8 to 10 hours or High for 4 to 6 hours.", 15 * time.Second}
+// IngredientGroup is one thing to gather: a single ingredient, or a
+// substitution group of which any one will do.
+type IngredientGroup struct {
+	// Name is the Group of its ingredients, or empty for one on its own.
+	Name        string
+	Ingredients []Ingredient
+}
 
-	default:
-		return CookingMethod{}
+// Optional is whether the whole group can be left out. A group with a
+// required member still needs one of its members.
+func (g IngredientGroup) Optional() bool {
+	for _, i := range g.Ingredients {
+		if !i.Optional {
+			return false
+		}
 	}
+
+	return true
 }
 
-func (r Recipe) GetImageSrc() string {
-	switch r {
-	case BuffaloChickenDip:
-		return "/static/buffalo_chicken_dip_pixel_art_small.png"
+// Gathered is whether any one of the group's ingredients is gathered.
+func (g IngredientGroup) Gathered(gathered map[string]bool) bool {
+	return slices.ContainsFunc(g.Ingredients, func(i Ingredient) bool {
+		return gathered[i.Name]
+	})
+}
 
-	case ChocolateChipCookies:
-		return "/static/chocolate_chip_cookies_small.png"
+// ListIngredientGroups lists the ingredients in order, with each substitution
+// group in the place of its first member.
+func (r Recipe) ListIngredientGroups() []IngredientGroup {
+	groups := []IngredientGroup{}
+	index := map[string]int{}
 
-	case PulledPork:
-		return "/static/hamburger_small.png"
+	for _, i := range r.Ingredients {
+		if i.Group == "" {
+			groups = append(groups, IngredientGroup{"", []Ingredient{i}})
+			continue
+		}
 
-	default:
-		return ""
+		n, ok := index[i.Group]
+		if !ok {
+			index[i.Group] = len(groups)
+			groups = append(groups, IngredientGroup{i.Group, []Ingredient{i}})
+			continue
+		}
+
+		groups[n].Ingredients = append(groups[n].Ingredients, i)
 	}
-}
 
-func ListRecipes() []Recipe {
-	list := []Recipe{}
+	return groups
+}
 
-	for r := range recipeName {
-		list = append(list, r)
+// FinishedGathering is whether everything the recipe needs is gathered:
+// optional ingredients can be skipped and one of each substitution group is
+// enough.
+func (r Recipe) FinishedGathering(gathered map[string]bool) bool {
+	for _, g := range r.ListIngredientGroups() {
+		if !g.Optional() && !g.Gathered(gathered) {
+			return false
+		}
 	}
 
-	return list
+	return true
 }
 
-func ParseRecipe(name string) (Recipe, error) {
-	for r, n := range recipeName {
-		if n == name {
-			return r, nil
-		}
+func (r Recipe) ListPrepTasks() []Task {
+	return r.Tasks
+}
+
+func (r Recipe) GetCookingMethod() CookingMethod {
+	return r.CookingMethod
+}
+
+// DefaultImageSrc is shown for recipes without a picture of their own.
+const DefaultImageSrc = "/static/hamburger_small.png"
+
+func (r Recipe) GetImageSrc() string {
+	if r.ImageSrc == "" {
+		return DefaultImageSrc
 	}
 
-	return -1, errors.New("invalid recipe name")
+	return r.ImageSrc
 }
 
 type Step int
@@ -205,6 +283,9 @@ func (s Step) GetNextStep() Step {
 	}
 }
 
+// Steps lists the steps a cook works through, in order.
+var Steps = []Step{Gather, Prepare, Cook}
+
 func GetFirstStep() Step {
 	return Gather
 }
@@ -0,0 +1,55 @@
+package recipes_test
+
+import (
+	"cooking-with-datastar/cmd/recipes"
+	"testing"
+)
+
+func TestFinishedGathering(t *testing.T) {
+	r := recipes.Recipe{
+		Name: "salad",
+		Ingredients: []recipes.Ingredient{
+			{Name: "lettuce", Description: "1 head of lettuce"},
+			{Name: "ranch", Description: "Ranch dressing", Group: "dressing"},
+			{Name: "vinaigrette", Description: "Vinaigrette", Group: "dressing"},
+			{Name: "croutons", Description: "Croutons", Optional: true},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		gathered map[string]bool
+		expected bool
+	}{
+		{"everything", map[string]bool{"lettuce": true, "ranch": true, "vinaigrette": true, "croutons": true}, true},
+		{"one of the group", map[string]bool{"lettuce": true, "vinaigrette": true}, true},
+		{"none of the group", map[string]bool{"lettuce": true, "croutons": true}, false},
+		{"a required ingredient missing", map[string]bool{"ranch": true, "croutons": true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.FinishedGathering(tt.gathered)
+			if got != tt.expected {
+				t.Logf("want '%v', got '%v'", tt.expected, got)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestListIngredientGroups(t *testing.T) {
+	groups := recipes.BuffaloChickenDip.ListIngredientGroups()
+
+	if len(groups) != len(recipes.BuffaloChickenDip.Ingredients)-1 {
+		t.Fatalf("want %d groups, got %d", len(recipes.BuffaloChickenDip.Ingredients)-1, len(groups))
+	}
+
+	// The dressings take the place of the first of them, after the cream
+	// cheese.
+	dressing := groups[2]
+	if dressing.Name != "dressing" || len(dressing.Ingredients) != 2 || dressing.Optional() {
+		t.Logf("want a required group of two dressings, got %+v", dressing)
+		t.Fail()
+	}
+}
@@ -0,0 +1,274 @@
+package recipes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = []Recipe{BuffaloChickenDip, ChocolateChipCookies, PulledPork}
+	// versions holds every version of each recipe by number. The registry
+	// holds the latest.
+	versions = map[string]map[int]Recipe{
+		BuffaloChickenDip.Name:    {1: BuffaloChickenDip},
+		ChocolateChipCookies.Name: {1: ChocolateChipCookies},
+		PulledPork.Name:           {1: PulledPork},
+	}
+)
+
+func ListRecipes() []Recipe {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	list := make([]Recipe, len(registry))
+	copy(list, registry)
+
+	return list
+}
+
+func ParseRecipe(name string) (Recipe, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, r := range registry {
+		if r.Name == name {
+			return r, nil
+		}
+	}
+
+	return Recipe{}, errors.New("invalid recipe name")
+}
+
+// ParseRecipeVersion returns an earlier, or the latest, version of a recipe.
+func ParseRecipeVersion(name string, version int) (Recipe, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	r, ok := versions[name][version]
+	if !ok {
+		return Recipe{}, fmt.Errorf("%s has no version %d", name, version)
+	}
+
+	return r, nil
+}
+
+// ListVersions returns every version of a recipe, oldest first.
+func ListVersions(name string) []Recipe {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	list := []Recipe{}
+	for _, v := range slices.Sorted(maps.Keys(versions[name])) {
+		list = append(list, versions[name][v])
+	}
+
+	return list
+}
+
+// ErrExists is returned by RegisterNew for a name that is already taken.
+var ErrExists = errors.New("recipe already exists")
+
+// Register validates a recipe and makes it the latest version of its name.
+// Versions never change once registered: a recipe that differs from the
+// latest becomes a new version, and one that does not is left as it is.
+func Register(r Recipe) (Recipe, error) {
+	return register(r, true)
+}
+
+// RegisterNew registers a recipe under a name no other recipe has, so that
+// an import cannot replace an existing recipe for everyone.
+func RegisterNew(r Recipe) (Recipe, error) {
+	return register(r, false)
+}
+
+func register(r Recipe, replace bool) (Recipe, error) {
+	if r.Name == "" {
+		return Recipe{}, errors.New("recipe is missing a name")
+	}
+
+	err := r.Validate()
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if !replace && len(versions[r.Name]) > 0 {
+		return Recipe{}, fmt.Errorf("%q: %w", r.Name, ErrExists)
+	}
+
+	latest := 0
+	for v := range versions[r.Name] {
+		latest = max(latest, v)
+	}
+
+	if latest > 0 && sameRecipe(versions[r.Name][latest], r) {
+		return versions[r.Name][latest], nil
+	}
+
+	r.Version = latest + 1
+	add(r)
+
+	return r, nil
+}
+
+// restore adds a version read back from disk with its own number.
+func restore(r Recipe) error {
+	if r.Name == "" {
+		return errors.New("recipe is missing a name")
+	}
+
+	if r.Version < 1 {
+		return fmt.Errorf("%s: version %d is not positive", r.Name, r.Version)
+	}
+
+	err := r.Validate()
+	if err != nil {
+		return err
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	existing, ok := versions[r.Name][r.Version]
+	if ok && !sameRecipe(existing, r) {
+		return fmt.Errorf("%s: version %d differs from the one already loaded", r.Name, r.Version)
+	}
+
+	add(r)
+
+	return nil
+}
+
+// add stores a version and, unless a later one is known, makes it the latest.
+// The caller holds registryMu.
+func add(r Recipe) {
+	if versions[r.Name] == nil {
+		versions[r.Name] = map[int]Recipe{}
+	}
+	versions[r.Name][r.Version] = r
+
+	for i, existing := range registry {
+		if existing.Name == r.Name {
+			if existing.Version <= r.Version {
+				registry[i] = r
+			}
+			return
+		}
+	}
+
+	registry = append(registry, r)
+}
+
+func sameRecipe(a Recipe, b Recipe) bool {
+	a.Version, b.Version = 0, 0
+
+	aj, aerr := json.Marshal(a)
+	bj, berr := json.Marshal(b)
+
+	return aerr == nil && berr == nil && bytes.Equal(aj, bj)
+}
+
+func ReadRecipeFile(path string) (Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	var r Recipe
+	err = json.Unmarshal(data, &r)
+	if err != nil {
+		return Recipe{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return r, nil
+}
+
+// WriteRecipeFile saves a recipe as <name>.json in dir, in the format that
+// LoadDir reads, and keeps a copy of the version under history/<name>.
+func WriteRecipeFile(dir string, r Recipe) (string, error) {
+	data, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return "", err
+	}
+	data = append(data, '\n')
+
+	if r.Version > 0 {
+		historyDir := filepath.Join(dir, "history", r.Name)
+
+		err := os.MkdirAll(historyDir, 0o755)
+		if err != nil {
+			return "", err
+		}
+
+		err = os.WriteFile(filepath.Join(historyDir, strconv.Itoa(r.Version)+".json"), data, 0o644)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	path := filepath.Join(dir, r.Name+".json")
+
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+// LoadDir registers every *.json recipe file in dir, after the earlier
+// versions kept under history/. A recipe file that has been edited by hand
+// since it was saved becomes a new version.
+func LoadDir(dir string) error {
+	history, err := filepath.Glob(filepath.Join(dir, "history", "*", "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, p := range history {
+		r, err := ReadRecipeFile(p)
+		if err != nil {
+			return err
+		}
+
+		err = restore(r)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, p := range paths {
+		r, err := ReadRecipeFile(p)
+		if err != nil {
+			return err
+		}
+
+		saved, err := ParseRecipeVersion(r.Name, r.Version)
+		switch {
+		case err == nil && sameRecipe(saved, r):
+			// Already loaded from history.
+
+		case err != nil && r.Version > 0:
+			err = restore(r)
+
+		default:
+			_, err = Register(r)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package recipes_test
+
+import (
+	"cooking-with-datastar/cmd/recipes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	r := recipes.Recipe{
+		Name:        "toast",
+		Ingredients: []recipes.Ingredient{{Name: "bread", Description: "2 slices of bread"}},
+		Tasks:       []recipes.Task{{"slice", "Slice the bread", []string{}}},
+		CookingMethod: recipes.CookingMethod{
+			Name:        "toast",
+			Description: "Toast until golden",
+			CookTime:    90 * time.Second,
+		},
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "toast.json"), data, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = recipes.LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := recipes.ParseRecipe("toast")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.GetCookingMethod().CookTime != 90*time.Second || loaded.ListIngredients()[0].Name != "bread" {
+		t.Logf("unexpected recipe %+v", loaded)
+		t.Fail()
+	}
+}
+
+func TestRegisterVersions(t *testing.T) {
+	r := recipes.Recipe{
+		Name:          "jam-toast",
+		Ingredients:   []recipes.Ingredient{{Name: "bread", Description: "2 slices of bread"}},
+		Tasks:         []recipes.Task{{"slice", "Slice the bread", []string{}}},
+		CookingMethod: recipes.CookingMethod{Name: "toast", Description: "Toast until golden", CookTime: 90 * time.Second},
+	}
+
+	first, err := recipes.Register(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	same, err := recipes.Register(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.CookingMethod.CookTime = 2 * time.Minute
+	second, err := recipes.Register(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Version != 1 || same.Version != 1 || second.Version != 2 {
+		t.Logf("want versions 1, 1 and 2, got %d, %d and %d", first.Version, same.Version, second.Version)
+		t.Fail()
+	}
+
+	pinned, err := recipes.ParseRecipeVersion("jam-toast", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pinned.CookingMethod.CookTime != 90*time.Second {
+		t.Logf("want '%s', got '%s'", 90*time.Second, pinned.CookingMethod.CookTime)
+		t.Fail()
+	}
+
+	latest, err := recipes.ParseRecipe("jam-toast")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest.Version != 2 || len(recipes.ListVersions("jam-toast")) != 2 {
+		t.Logf("want version 2 of 2, got %d of %d", latest.Version, len(recipes.ListVersions("jam-toast")))
+		t.Fail()
+	}
+}
+
+func TestRegisterNew(t *testing.T) {
+	_, err := recipes.RegisterNew(recipes.PulledPork)
+	if !errors.Is(err, recipes.ErrExists) {
+		t.Logf("want '%v', got '%v'", recipes.ErrExists, err)
+		t.Fail()
+	}
+
+	latest, err := recipes.ParseRecipe(recipes.PulledPork.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest.Version != 1 {
+		t.Logf("want version 1, got %d", latest.Version)
+		t.Fail()
+	}
+
+	r := recipes.Recipe{
+		Name:          "plain-toast",
+		Ingredients:   []recipes.Ingredient{{Name: "bread", Description: "1 slice of bread"}},
+		Tasks:         []recipes.Task{},
+		CookingMethod: recipes.CookingMethod{Name: "toast", Description: "Toast", CookTime: time.Minute},
+	}
+
+	_, err = recipes.RegisterNew(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteRecipeFileKeepsHistory(t *testing.T) {
+	dir := t.TempDir()
+
+	r := recipes.Recipe{
+		Name:          "cheese-toast",
+		Ingredients:   []recipes.Ingredient{{Name: "bread", Description: "2 slices of bread"}},
+		Tasks:         []recipes.Task{{"slice", "Slice the bread", []string{}}},
+		CookingMethod: recipes.CookingMethod{Name: "grill", Description: "Grill until bubbling", CookTime: 3 * time.Minute},
+	}
+
+	for _, cookTime := range []time.Duration{3 * time.Minute, 4 * time.Minute} {
+		r.CookingMethod.CookTime = cookTime
+
+		saved, err := recipes.Register(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = recipes.WriteRecipeFile(dir, saved)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"cheese-toast.json", "history/cheese-toast/1.json", "history/cheese-toast/2.json"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+	}
+
+	// Loading the files again finds the versions already registered.
+	err := recipes.LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(recipes.ListVersions("cheese-toast")); got != 2 {
+		t.Logf("want 2 versions, got %d", got)
+		t.Fail()
+	}
+}
@@ -0,0 +1,54 @@
+package recipes
+
+import "slices"
+
+// Translation holds a recipe's text in one locale. Descriptions and Labels
+// are keyed by ingredient, task or cooking method name. Anything missing
+// falls back to the recipe's own text.
+type Translation struct {
+	Title        string            `json:"title,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+}
+
+// Translate returns a copy of the recipe with the descriptions for the given
+// locale. Names are left alone, since they are used in URLs and cookies.
+func (r Recipe) Translate(locale string) Recipe {
+	t, ok := r.Translations[locale]
+	if !ok {
+		return r
+	}
+
+	r.Ingredients = slices.Clone(r.Ingredients)
+	for i, ingredient := range r.Ingredients {
+		if d, ok := t.Descriptions[ingredient.Name]; ok {
+			r.Ingredients[i].Description = d
+		}
+	}
+
+	r.Tasks = slices.Clone(r.Tasks)
+	for i, task := range r.Tasks {
+		if d, ok := t.Descriptions[task.Name]; ok {
+			r.Tasks[i].Description = d
+		}
+	}
+
+	if d, ok := t.Descriptions[r.CookingMethod.Name]; ok {
+		r.CookingMethod.Description = d
+	}
+
+	return r
+}
+
+// Title is the recipe's translated title, if it has one.
+func (r Recipe) Title(locale string) (string, bool) {
+	t := r.Translations[locale].Title
+	return t, t != ""
+}
+
+// Label is the translated button label for an ingredient, task or cooking
+// method, if it has one.
+func (r Recipe) Label(locale string, name string) (string, bool) {
+	l, ok := r.Translations[locale].Labels[name]
+	return l, ok
+}
@@ -0,0 +1,46 @@
+package recipes_test
+
+import (
+	"cooking-with-datastar/cmd/recipes"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	r := recipes.PulledPork
+
+	translated := r.Translate("es")
+
+	tests := []struct {
+		name     string
+		result   string
+		expected string
+	}{
+		{"ingredient", translated.ListIngredients()[1].Description, "1 taza de kétchup"},
+		{"task", translated.ListPrepTasks()[0].Description, "Coloca la paleta de cerdo en una olla de cocción lenta."},
+		{"cooking method", translated.GetCookingMethod().Description, "Cocina a fuego bajo de 8 a 10 horas o a fuego alto de 4 a 6 horas."},
+		{"name is kept", translated.ListPrepTasks()[0].Name, "place"},
+		{"original is untouched", r.ListIngredients()[1].Description, "1 cup ketchup"},
+		{"unknown locale", r.Translate("fr").ListIngredients()[1].Description, "1 cup ketchup"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.result != tc.expected {
+				t.Logf("want '%s', got '%s'", tc.expected, tc.result)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestValidateTranslations(t *testing.T) {
+	r := recipes.PulledPork
+	r.Translations = map[string]recipes.Translation{
+		"es": {Descriptions: map[string]string{"brisket": "Pecho de res"}},
+	}
+
+	err := r.Validate()
+	if err == nil {
+		t.Fatal("want an error for a translation of an unknown name")
+	}
+}
@@ -0,0 +1,90 @@
+package recipes
+
+// Translations of the built-in recipes. Quantities use a decimal point here
+// and are formatted for the locale when they are shown.
+
+var buffaloChickenDipES = Translation{
+	Title: "Dip de pollo búfalo",
+	Labels: map[string]string{
+		"cook-the-chicken": "Cocer el pollo",
+		"shred":            "Deshebrar",
+		"heat-the-oven":    "Calentar el horno",
+		"cube":             "Cortar en cubos",
+		"warm-the-sauce":   "Calentar la salsa",
+		"prep-the-pan":     "Preparar el molde",
+		"combine":          "Combinar",
+		"bake":             "Hornear",
+	},
+	Descriptions: map[string]string{
+		"chicken":              "3 pechugas de pollo grandes, deshuesadas y sin piel",
+		"cream-cheese":         "8 onzas de queso crema",
+		"ranch-dressing":       "1 taza de aderezo ranch",
+		"blue-cheese-dressing": "1 taza de aderezo de queso azul",
+		"hot-sauce":            "1 taza de salsa picante",
+		"black-pepper":         "1 cucharadita de pimienta negra recién molida",
+		"garlic-powder":        "1 cucharadita de ajo en polvo",
+		"green-onion":          "0.5 taza de cebollín",
+		"mozzarella-cheese":    "1.5 tazas de queso mozzarella",
+		"cheddar-cheese":       "1.5 tazas de queso cheddar",
+		"cook-the-chicken":     "Escalfa el pollo durante unos 25 minutos. Cuando esté bien cocido, sácalo de la olla y déjalo enfriar hasta que se pueda manipular.",
+		"shred":                "Deshebra el pollo en el procesador de alimentos.",
+		"heat-the-oven":        "Precalienta el horno a 350 grados Fahrenheit.",
+		"cube":                 "Corta el queso crema en cubos de 1 pulgada.",
+		"warm-the-sauce":       "Calienta una olla mediana a fuego medio-bajo. Añade el queso crema en cubos, el aderezo, la salsa picante, la pimienta negra y el ajo en polvo. Bate sin parar hasta que el queso crema se disuelva. Retira del fuego.",
+		"prep-the-pan":         "Rocía un molde de 9x9 pulgadas con aceite en aerosol.",
+		"combine":              "Mezcla el pollo deshebrado, la salsa, el cebollín y el queso en una olla grande. Pásalo al molde para hornear.",
+		"bake":                 "Hornea de 20 a 30 minutos, o hasta que el queso se derrita y los bordes empiecen a burbujear.",
+	},
+}
+
+var chocolateChipCookiesES = Translation{
+	Title: "Galletas con chispas de chocolate",
+	Labels: map[string]string{
+		"heat-the-oven":   "Calentar el horno",
+		"beat-eggs":       "Batir los huevos",
+		"add-baking-soda": "Añadir el bicarbonato",
+		"stir-in-flour":   "Incorporar la harina",
+		"place-dough":     "Colocar la masa",
+		"bake":            "Hornear",
+	},
+	Descriptions: map[string]string{
+		"butter":          "1 taza de mantequilla, blanda",
+		"white-sugar":     "1 taza de azúcar blanca",
+		"brown-sugar":     "1 taza de azúcar morena compacta",
+		"eggs":            "2 huevos grandes",
+		"vanilla":         "2 cucharaditas de extracto de vainilla",
+		"baking-soda":     "1 cucharadita de bicarbonato de sodio",
+		"hot-water":       "2 cucharaditas de agua caliente",
+		"salt":            "0.5 cucharadita de sal",
+		"flour":           "3 tazas de harina de trigo",
+		"chocolate-chips": "2 tazas de chispas de chocolate semiamargo",
+		"walnuts":         "1 taza de nueces picadas",
+		"heat-the-oven":   "Precalienta el horno a 350 grados Fahrenheit.",
+		"beat-eggs":       "Incorpora los huevos de uno en uno y luego añade la vainilla.",
+		"add-baking-soda": "Disuelve el bicarbonato en el agua caliente. Añádelo a la masa junto con la sal.",
+		"stir-in-flour":   "Incorpora la harina, las chispas de chocolate y las nueces.",
+		"place-dough":     "Coloca cucharadas de masa separadas 2 pulgadas sobre bandejas para hornear sin engrasar.",
+		"bake":            "Hornea de 10 a 12 minutos",
+	},
+}
+
+var pulledPorkES = Translation{
+	Title: "Cerdo deshebrado",
+	Labels: map[string]string{
+		"place":     "Colocar",
+		"combine":   "Combinar",
+		"pour":      "Verter",
+		"slow-cook": "Cocción lenta",
+	},
+	Descriptions: map[string]string{
+		"pork-shoulder": "3 libras de paleta de cerdo deshuesada",
+		"ketchup":       "1 taza de kétchup",
+		"brown-sugar":   "0.5 taza de azúcar morena compacta",
+		"vinegar":       "0.25 taza de vinagre de manzana",
+		"hot-sauce":     "Salsa picante al gusto",
+		"place":         "Coloca la paleta de cerdo en una olla de cocción lenta.",
+		"combine":       "Bate el kétchup, el azúcar morena, el vinagre y la salsa picante en un tazón hasta que estén bien mezclados",
+		"pour":          "Vierte la mezcla sobre el cerdo. Dale la vuelta para cubrirlo por completo.",
+		"slow-cook":     "Cocina a fuego bajo de 8 a 10 horas o a fuego alto de 4 a 6 horas.",
+	},
+}
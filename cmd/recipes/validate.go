@@ -0,0 +1,225 @@
+package recipes
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+)
+
+// namePattern matches the hyphenated names used for recipes, cooking methods,
+// ingredients and tasks, which become cookie names and, through ToCamelCase,
+// signal names.
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// ValidateName checks a name of the given kind, e.g. "ingredient", against
+// namePattern.
+func ValidateName(kind string, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s is missing a name", kind)
+	}
+
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("%s name %q must be lowercase words joined by hyphens, e.g. brown-sugar", kind, name)
+	}
+
+	return nil
+}
+
+func (r Recipe) Validate() error {
+	err := errors.Join(
+		ValidateName("recipe", r.Name),
+		ValidateName("cooking method", r.CookingMethod.Name),
+		Validate(r.ListIngredients(), r.ListPrepTasks()),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.String(), err)
+	}
+
+	// The cooking method's button sits on the same page as the tasks' and is
+	// named the same way.
+	if slices.ContainsFunc(r.Tasks, func(t Task) bool { return t.Name == r.CookingMethod.Name }) {
+		return fmt.Errorf("%s: cooking method %q has the same name as a task", r.String(), r.CookingMethod.Name)
+	}
+
+	err = r.validateTranslations()
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.String(), err)
+	}
+
+	return nil
+}
+
+// validateTranslations catches translations keyed by a name the recipe does
+// not have, which would otherwise be silently ignored.
+func (r Recipe) validateTranslations() error {
+	names := map[string]bool{r.CookingMethod.Name: true}
+	for _, i := range r.Ingredients {
+		names[i.Name] = true
+	}
+	for _, t := range r.Tasks {
+		names[t.Name] = true
+	}
+
+	errs := []error{}
+	for _, locale := range slices.Sorted(maps.Keys(r.Translations)) {
+		t := r.Translations[locale]
+
+		for _, key := range slices.Sorted(maps.Keys(t.Descriptions)) {
+			if !names[key] {
+				errs = append(errs, fmt.Errorf("%s translation describes unknown name %q", locale, key))
+			}
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(t.Labels)) {
+			if !names[key] {
+				errs = append(errs, fmt.Errorf("%s translation labels unknown name %q", locale, key))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate checks that ingredient and task names are well formed and unique,
+// that quantities
+// and aisles are known, that each substitution group has more than one
+// ingredient and that the task dependencies name existing tasks without
+// forming a cycle.
+func Validate(ingredients []Ingredient, tasks []Task) error {
+	errs := []error{}
+
+	ingredientNames := map[string]bool{}
+	for _, i := range ingredients {
+		err := ValidateName("ingredient", i.Name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		if i.Group != "" {
+			err := ValidateName("substitution group", i.Group)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if ingredientNames[i.Name] {
+			errs = append(errs, fmt.Errorf("duplicate ingredient %q", i.Name))
+		}
+
+		ingredientNames[i.Name] = true
+
+		if i.Aisle != "" && !slices.Contains(Aisles, i.Aisle) {
+			errs = append(errs, fmt.Errorf("ingredient %q is in an unknown aisle %q", i.Name, i.Aisle))
+		}
+
+		if i.Quantity != nil {
+			_, ok := i.Quantity.Convert(i.Quantity.Unit)
+			if !ok || i.Quantity.Amount < 0 {
+				errs = append(errs, fmt.Errorf("ingredient %q has an invalid quantity %v", i.Name, *i.Quantity))
+			}
+		}
+	}
+
+	// A group of one has nothing to substitute, which is most likely a typo
+	// in the other member's group.
+	groupSizes := map[string]int{}
+	for _, i := range ingredients {
+		if i.Group != "" {
+			groupSizes[i.Group]++
+		}
+	}
+	for _, g := range slices.Sorted(maps.Keys(groupSizes)) {
+		if groupSizes[g] == 1 {
+			errs = append(errs, fmt.Errorf("substitution group %q has only one ingredient", g))
+		}
+	}
+
+	taskNames := map[string]bool{}
+	for _, t := range tasks {
+		err := ValidateName("task", t.Name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		if taskNames[t.Name] {
+			errs = append(errs, fmt.Errorf("duplicate task %q", t.Name))
+		}
+
+		taskNames[t.Name] = true
+	}
+
+	for _, t := range tasks {
+		for _, d := range t.Dependencies {
+			if !taskNames[d] {
+				errs = append(errs, fmt.Errorf("task %q depends on unknown task %q", t.Name, d))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	_, err := SortTasks(tasks)
+
+	return err
+}
+
+// SortTasks orders tasks so that every task comes after its dependencies.
+// Tasks keep their listed order where the dependencies allow it.
+func SortTasks(tasks []Task) ([]Task, error) {
+	byName := map[string]Task{}
+	for _, t := range tasks {
+		byName[t.Name] = t
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := map[string]int{}
+	sorted := []Task{}
+
+	var visit func(t Task, path []string) error
+	visit = func(t Task, path []string) error {
+		switch state[t.Name] {
+		case visited:
+			return nil
+
+		case visiting:
+			return fmt.Errorf("dependency cycle %v", append(path, t.Name))
+		}
+
+		state[t.Name] = visiting
+
+		for _, d := range t.Dependencies {
+			dep, ok := byName[d]
+			if !ok {
+				return fmt.Errorf("task %q depends on unknown task %q", t.Name, d)
+			}
+
+			err := visit(dep, append(path, t.Name))
+			if err != nil {
+				return err
+			}
+		}
+
+		state[t.Name] = visited
+		sorted = append(sorted, t)
+
+		return nil
+	}
+
+	for _, t := range tasks {
+		err := visit(t, []string{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
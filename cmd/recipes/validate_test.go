@@ -0,0 +1,168 @@
+package recipes_test
+
+import (
+	"cooking-with-datastar/cmd/recipes"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		ingredients []recipes.Ingredient
+		tasks       []recipes.Task
+		valid       bool
+	}{
+		{
+			"valid",
+			[]recipes.Ingredient{{Name: "salt", Description: "A pinch of salt"}},
+			[]recipes.Task{{"boil", "Boil water", []string{}}, {"season", "Season the water", []string{"boil"}}},
+			true,
+		},
+		{
+			"duplicate ingredient",
+			[]recipes.Ingredient{{Name: "salt", Description: "A pinch of salt"}, {Name: "salt", Description: "More salt"}},
+			[]recipes.Task{},
+			false,
+		},
+		{
+			"substitution group",
+			[]recipes.Ingredient{{Name: "ranch", Description: "Ranch dressing", Group: "dressing"}, {Name: "blue-cheese", Description: "Blue cheese dressing", Group: "dressing"}},
+			[]recipes.Task{},
+			true,
+		},
+		{
+			"group of one",
+			[]recipes.Ingredient{{Name: "ranch", Description: "Ranch dressing", Group: "dressing"}, {Name: "blue-cheese", Description: "Blue cheese dressing", Group: "dresing"}},
+			[]recipes.Task{},
+			false,
+		},
+		{
+			"quantity",
+			[]recipes.Ingredient{{Name: "salt", Description: "Salt", Quantity: &recipes.Quantity{Amount: 1, Unit: "teaspoon"}}},
+			[]recipes.Task{},
+			true,
+		},
+		{
+			"unknown unit",
+			[]recipes.Ingredient{{Name: "salt", Description: "Salt", Quantity: &recipes.Quantity{Amount: 1, Unit: "pinch"}}},
+			[]recipes.Task{},
+			false,
+		},
+		{
+			"unknown aisle",
+			[]recipes.Ingredient{{Name: "salt", Description: "Salt", Aisle: "seasoning"}},
+			[]recipes.Task{},
+			false,
+		},
+		{
+			"ingredient name",
+			[]recipes.Ingredient{{Name: "Bread Slice", Description: "A slice of bread"}},
+			[]recipes.Task{},
+			false,
+		},
+		{
+			"group name",
+			[]recipes.Ingredient{{Name: "ranch", Description: "Ranch dressing", Group: "Dressing"}, {Name: "blue-cheese", Description: "Blue cheese dressing", Group: "Dressing"}},
+			[]recipes.Task{},
+			false,
+		},
+		{
+			"task name",
+			[]recipes.Ingredient{},
+			[]recipes.Task{{"toast it", "", []string{}}},
+			false,
+		},
+		{
+			"duplicate task",
+			[]recipes.Ingredient{},
+			[]recipes.Task{{"boil", "", []string{}}, {"boil", "", []string{}}},
+			false,
+		},
+		{
+			"unknown dependency",
+			[]recipes.Ingredient{},
+			[]recipes.Task{{"season", "", []string{"boil"}}},
+			false,
+		},
+		{
+			"cycle",
+			[]recipes.Ingredient{},
+			[]recipes.Task{{"a", "", []string{"c"}}, {"b", "", []string{"a"}}, {"c", "", []string{"b"}}},
+			false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := recipes.Validate(tc.ingredients, tc.tasks)
+
+			if (err == nil) != tc.valid {
+				t.Logf("want valid %v, got error '%v'", tc.valid, err)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestRecipesAreValid(t *testing.T) {
+	for _, r := range recipes.ListRecipes() {
+		err := r.Validate()
+		if err != nil {
+			t.Log(err)
+			t.Fail()
+		}
+	}
+}
+
+func TestValidateCookingMethodName(t *testing.T) {
+	r := recipes.Recipe{
+		Name:          "toast",
+		Ingredients:   []recipes.Ingredient{{Name: "bread", Description: "1 slice of bread"}},
+		Tasks:         []recipes.Task{{"toast", "Toast the bread", []string{}}},
+		CookingMethod: recipes.CookingMethod{Name: "toast", Description: "Toast", CookTime: 1},
+	}
+
+	err := r.Validate()
+	if err == nil {
+		t.Log("want an error for a cooking method named like a task")
+		t.Fail()
+	}
+}
+
+func TestValidateRecipeName(t *testing.T) {
+	r := recipes.Recipe{
+		Name:          "Bad Name",
+		Ingredients:   []recipes.Ingredient{{Name: "bread", Description: "1 slice of bread"}},
+		Tasks:         []recipes.Task{{"slice", "Slice the bread", []string{}}},
+		CookingMethod: recipes.CookingMethod{Name: "toast", Description: "Toast", CookTime: 1},
+	}
+
+	err := r.Validate()
+	if err == nil {
+		t.Log("want an error for a recipe name with spaces")
+		t.Fail()
+	}
+}
+
+func TestSortTasks(t *testing.T) {
+	tasks := []recipes.Task{
+		{"combine", "", []string{"shred", "cube"}},
+		{"shred", "", []string{"cook"}},
+		{"cook", "", []string{}},
+		{"cube", "", []string{}},
+	}
+
+	sorted, err := recipes.SortTasks(tasks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"cook", "shred", "cube", "combine"}
+
+	for i, e := range expected {
+		if sorted[i].Name != e {
+			t.Logf("want '%s' at %d, got '%s'", e, i, sorted[i].Name)
+			t.Fail()
+		}
+	}
+}
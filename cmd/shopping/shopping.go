@@ -0,0 +1,223 @@
+// Package shopping merges the ingredients of several recipes into one list,
+// with the amounts of an ingredient that recipes share added up and the
+// items grouped by the aisle they are found in.
+package shopping
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/i18n"
+	"cooking-with-datastar/cmd/recipes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Use is one recipe's need for an item.
+type Use struct {
+	Recipe      string `json:"recipe"`
+	Description string `json:"description"`
+}
+
+type Item struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Aisle string `json:"aisle"`
+	// Quantities has one total for each kind of unit, since cups and pounds
+	// cannot be added.
+	Quantities []recipes.Quantity `json:"quantities,omitempty"`
+	// ToTaste is set when a recipe uses the item without saying how much.
+	ToTaste bool `json:"toTaste,omitempty"`
+	// Optional is set when every recipe can do without the item.
+	Optional bool `json:"optional,omitempty"`
+	// Alternatives are the items that can stand in for this one.
+	Alternatives []string `json:"alternatives,omitempty"`
+	Uses         []Use    `json:"uses"`
+}
+
+type Aisle struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Items []Item `json:"items"`
+}
+
+type List struct {
+	Recipes []string `json:"recipes"`
+	Titles  []string `json:"titles"`
+	Aisles  []Aisle  `json:"aisles"`
+}
+
+// New merges the recipes' ingredients by name. Labels and descriptions are
+// in the request's locale.
+func New(ctx context.Context, list []recipes.Recipe) List {
+	l := List{Recipes: []string{}, Titles: []string{}, Aisles: []Aisle{}}
+
+	items := map[string]*Item{}
+	order := []string{}
+
+	for _, r := range list {
+		l.Recipes = append(l.Recipes, r.String())
+		l.Titles = append(l.Titles, i18n.Title(ctx, r))
+
+		// Quantities are read from the recipe as written, since a localized
+		// description has its numbers and units in the locale's language.
+		descriptions := map[string]string{}
+		for _, i := range i18n.Localize(ctx, r).Ingredients {
+			descriptions[i.Name] = i.Description
+		}
+
+		for _, g := range r.ListIngredientGroups() {
+			for _, i := range g.Ingredients {
+				item, ok := items[i.Name]
+				if !ok {
+					item = &Item{Name: i.Name, Label: i18n.Label(ctx, r, i.Name), Aisle: i.Aisle, Optional: true}
+					items[i.Name] = item
+					order = append(order, i.Name)
+				}
+
+				if item.Aisle == "" {
+					item.Aisle = i.Aisle
+				}
+
+				item.Uses = append(item.Uses, Use{r.String(), descriptions[i.Name]})
+				item.Optional = item.Optional && (i.Optional || g.Optional())
+
+				for _, other := range g.Ingredients {
+					if other.Name != i.Name && !slices.Contains(item.Alternatives, other.Name) {
+						item.Alternatives = append(item.Alternatives, other.Name)
+					}
+				}
+
+				q, ok := i.GetQuantity()
+				if !ok {
+					item.ToTaste = true
+					continue
+				}
+
+				item.Quantities = add(item.Quantities, q)
+			}
+		}
+	}
+
+	for _, aisle := range recipes.Aisles {
+		a := Aisle{Name: aisle, Label: i18n.T(ctx, "shopping.aisle."+aisle), Items: []Item{}}
+
+		for _, name := range order {
+			item := items[name]
+			if item.Aisle == aisle || (aisle == "other" && !slices.Contains(recipes.Aisles, item.Aisle)) {
+				a.Items = append(a.Items, *item)
+			}
+		}
+
+		if len(a.Items) == 0 {
+			continue
+		}
+
+		slices.SortFunc(a.Items, func(x, y Item) int {
+			return strings.Compare(x.Label, y.Label)
+		})
+
+		l.Aisles = append(l.Aisles, a)
+	}
+
+	return l
+}
+
+// add sums q into the first total it can be converted to, or starts a new one.
+func add(totals []recipes.Quantity, q recipes.Quantity) []recipes.Quantity {
+	for i, t := range totals {
+		sum, ok := t.Add(q)
+		if ok {
+			totals[i] = sum
+			return totals
+		}
+	}
+
+	return append(totals, q)
+}
+
+// Items is how many items are on the list.
+func (l List) Items() int {
+	n := 0
+	for _, a := range l.Aisles {
+		n += len(a.Items)
+	}
+
+	return n
+}
+
+// Amount describes how much of an item to buy, e.g. "1.5 cups" or "1 cup,
+// plus more to taste".
+func Amount(ctx context.Context, item Item) string {
+	amounts := []string{}
+	for _, q := range item.Quantities {
+		amounts = append(amounts, q.String())
+	}
+	text := i18n.FormatNumbers(i18n.Locale(ctx), strings.Join(amounts, " + "))
+
+	switch {
+	case item.ToTaste && text == "":
+		text = i18n.T(ctx, "shopping.to-taste")
+
+	case item.ToTaste:
+		text = i18n.T(ctx, "shopping.plus-to-taste", text)
+	}
+
+	return text
+}
+
+// Notes lists what else to know about an item: that it is optional, or what
+// can be bought instead.
+func Notes(ctx context.Context, l List, item Item) []string {
+	notes := []string{}
+
+	if item.Optional {
+		notes = append(notes, i18n.T(ctx, "gather.optional"))
+	}
+
+	if len(item.Alternatives) > 0 {
+		labels := []string{}
+		for _, name := range item.Alternatives {
+			labels = append(labels, l.label(name))
+		}
+		notes = append(notes, i18n.T(ctx, "shopping.or", strings.Join(labels, ", ")))
+	}
+
+	return notes
+}
+
+func (l List) label(name string) string {
+	for _, a := range l.Aisles {
+		for _, item := range a.Items {
+			if item.Name == name {
+				return item.Label
+			}
+		}
+	}
+
+	return name
+}
+
+// Text writes the list as plain text, to paste into a note or a message.
+func Text(ctx context.Context, l List) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: %s\n", i18n.T(ctx, "shopping.title"), strings.Join(l.Titles, ", "))
+
+	for _, a := range l.Aisles {
+		fmt.Fprintf(&b, "\n%s\n", a.Label)
+
+		for _, item := range a.Items {
+			line := item.Label
+			if amount := Amount(ctx, item); amount != "" {
+				line += ": " + amount
+			}
+			if notes := Notes(ctx, l, item); len(notes) > 0 {
+				line += " (" + strings.Join(notes, "; ") + ")"
+			}
+
+			fmt.Fprintf(&b, "- [ ] %s\n", line)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package shopping_test
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/recipes"
+	"cooking-with-datastar/cmd/shopping"
+	"strings"
+	"testing"
+)
+
+func find(l shopping.List, name string) (shopping.Item, string) {
+	for _, a := range l.Aisles {
+		for _, item := range a.Items {
+			if item.Name == name {
+				return item, a.Name
+			}
+		}
+	}
+
+	return shopping.Item{}, ""
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		recipes  []recipes.Recipe
+		item     string
+		aisle    string
+		amount   string
+		notes    string
+		optional bool
+		uses     int
+	}{
+		{
+			name:    "shared hot sauce",
+			recipes: []recipes.Recipe{recipes.BuffaloChickenDip, recipes.PulledPork},
+			item:    "hot-sauce",
+			aisle:   "condiments",
+			amount:  "1 cup, plus more to taste",
+			uses:    2,
+		},
+		{
+			name:    "brown sugar summed across units",
+			recipes: []recipes.Recipe{recipes.ChocolateChipCookies, recipes.PulledPork},
+			item:    "brown-sugar",
+			aisle:   "baking",
+			amount:  "1.5 cups",
+			uses:    2,
+		},
+		{
+			name:    "substitution group",
+			recipes: []recipes.Recipe{recipes.BuffaloChickenDip},
+			item:    "ranch-dressing",
+			aisle:   "condiments",
+			amount:  "1 cup",
+			notes:   "or Blue cheese dressing",
+			uses:    1,
+		},
+		{
+			name:     "optional",
+			recipes:  []recipes.Recipe{recipes.ChocolateChipCookies},
+			item:     "walnuts",
+			aisle:    "baking",
+			amount:   "1 cup",
+			notes:    "optional",
+			optional: true,
+			uses:     1,
+		},
+		{
+			name:    "no aisle",
+			recipes: []recipes.Recipe{recipes.ChocolateChipCookies},
+			item:    "hot-water",
+			aisle:   "other",
+			amount:  "2 teaspoons",
+			uses:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := shopping.New(ctx, tt.recipes)
+
+			item, aisle := find(l, tt.item)
+			if aisle != tt.aisle {
+				t.Logf("aisle: want '%s', got '%s'", tt.aisle, aisle)
+				t.Fail()
+			}
+
+			got := shopping.Amount(ctx, item)
+			if got != tt.amount {
+				t.Logf("amount: want '%s', got '%s'", tt.amount, got)
+				t.Fail()
+			}
+
+			notes := strings.Join(shopping.Notes(ctx, l, item), "; ")
+			if notes != tt.notes {
+				t.Logf("notes: want '%s', got '%s'", tt.notes, notes)
+				t.Fail()
+			}
+
+			if item.Optional != tt.optional {
+				t.Logf("optional: want %v, got %v", tt.optional, item.Optional)
+				t.Fail()
+			}
+
+			if len(item.Uses) != tt.uses {
+				t.Logf("uses: want %d, got %d", tt.uses, len(item.Uses))
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestAisleOrder(t *testing.T) {
+	l := shopping.New(context.Background(), recipes.ListRecipes())
+
+	next := 0
+	for _, a := range l.Aisles {
+		for next < len(recipes.Aisles) && recipes.Aisles[next] != a.Name {
+			next++
+		}
+
+		if next == len(recipes.Aisles) {
+			t.Logf("aisle '%s' is out of order", a.Name)
+			t.Fail()
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	ctx := context.Background()
+	l := shopping.New(ctx, []recipes.Recipe{recipes.BuffaloChickenDip, recipes.PulledPork})
+
+	got := shopping.Text(ctx, l)
+
+	for _, want := range []string{
+		"Shopping list: Buffalo chicken dip, Pulled pork\n",
+		"\nCondiments\n",
+		"- [ ] Hot sauce: 1 cup, plus more to taste\n",
+		"- [ ] Blue cheese dressing: 1 cup (or Ranch dressing)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Logf("want '%s' in '%s'", want, got)
+			t.Fail()
+		}
+	}
+}
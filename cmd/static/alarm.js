@@ -0,0 +1,70 @@
+// Lets the cook know their food is done when the tab is in the background.
+// The cook stream dispatches "timer-finished" on the document once the
+// countdown ends; the alarm keeps going until it is acknowledged.
+const BEEP_EVERY_MS = 2000;
+
+let audio = null;
+let beeping = null;
+let notification = null;
+
+// Browsers only let a page play sound after the user has interacted with it,
+// so create the audio context on the first tap or click.
+document.addEventListener(
+	"pointerdown",
+	() => {
+		audio ??= new AudioContext();
+	},
+	{ once: true },
+);
+
+window.requestNotificationPermission = () => {
+	if ("Notification" in window && Notification.permission === "default") {
+		Notification.requestPermission();
+	}
+};
+
+window.stopAlarm = () => {
+	clearInterval(beeping);
+	beeping = null;
+
+	notification?.close();
+	notification = null;
+};
+
+document.addEventListener("timer-finished", (event) => {
+	const { title, body, icon, notify, sound } = event.detail;
+
+	if (notify && "Notification" in window && Notification.permission === "granted") {
+		notification = new Notification(title, { body, icon, requireInteraction: true, tag: "timer-finished" });
+		notification.onclick = () => {
+			window.focus();
+			window.stopAlarm();
+			document.getElementById("timer-finished")?.close();
+		};
+	}
+
+	if (sound && beeping === null) {
+		beep();
+		beeping = setInterval(beep, BEEP_EVERY_MS);
+	}
+});
+
+function beep() {
+	audio ??= new AudioContext();
+
+	// Three short tones, like a kitchen timer.
+	for (let i = 0; i < 3; i++) {
+		const start = audio.currentTime + i * 0.25;
+		const oscillator = audio.createOscillator();
+		const gain = audio.createGain();
+
+		oscillator.type = "square";
+		oscillator.frequency.value = 880;
+		gain.gain.setValueAtTime(0.2, start);
+		gain.gain.exponentialRampToValueAtTime(0.001, start + 0.2);
+
+		oscillator.connect(gain).connect(audio.destination);
+		oscillator.start(start);
+		oscillator.stop(start + 0.2);
+	}
+}
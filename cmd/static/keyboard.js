@@ -0,0 +1,34 @@
+// Arrow keys move between prep tasks, skipping the ones that can't be started
+// yet, so a keyboard user doesn't have to tab through every progress bar.
+document.addEventListener("keydown", (event) => {
+	const list = event.target.closest?.("#prep-tasks");
+	if (!list || event.target.tagName !== "BUTTON") {
+		return;
+	}
+
+	const buttons = [...list.querySelectorAll("button:not([disabled])")];
+	const i = buttons.indexOf(event.target);
+
+	let next = null;
+	switch (event.key) {
+		case "ArrowDown":
+		case "ArrowRight":
+			next = buttons[(i + 1) % buttons.length];
+			break;
+		case "ArrowUp":
+		case "ArrowLeft":
+			next = buttons[(i - 1 + buttons.length) % buttons.length];
+			break;
+		case "Home":
+			next = buttons[0];
+			break;
+		case "End":
+			next = buttons[buttons.length - 1];
+			break;
+		default:
+			return;
+	}
+
+	event.preventDefault();
+	next?.focus();
+});
@@ -0,0 +1,14 @@
+// Reads steps aloud. The server dispatches "narrate" on the document with the
+// text to speak, either when the cook asks for it or when a step changes.
+document.addEventListener("narrate", (event) => {
+	if (!("speechSynthesis" in window)) {
+		return;
+	}
+
+	const utterance = new SpeechSynthesisUtterance(event.detail.text);
+	utterance.lang = document.documentElement.lang || "en";
+
+	// Don't queue up a backlog of steps the cook has already moved past.
+	speechSynthesis.cancel();
+	speechSynthesis.speak(utterance);
+});
@@ -0,0 +1,82 @@
+// Hands-free mode: recognised phrases are posted to /voice/{recipe}, which
+// answers with the patches to apply. Recognition runs in the browser, so the
+// toggle only shows up where it is supported.
+const Recognition = window.SpeechRecognition ?? window.webkitSpeechRecognition;
+
+let recognition = null;
+let listening = false;
+
+function form() {
+	return document.getElementById("voice-form");
+}
+
+function render() {
+	const toggle = document.getElementById("voice-toggle");
+	if (!toggle) {
+		return;
+	}
+
+	// Only touch the DOM when something changed, since this also runs on
+	// every mutation.
+	const label = listening ? toggle.dataset.labelOn : toggle.dataset.labelOff;
+	if (form().hidden || toggle.textContent.trim() !== label) {
+		form().hidden = false;
+		toggle.setAttribute("aria-pressed", String(listening));
+		toggle.textContent = label;
+	}
+}
+
+function start() {
+	recognition = new Recognition();
+	recognition.continuous = true;
+	recognition.interimResults = false;
+	// Commands are matched in English whatever the page language is.
+	recognition.lang = "en-US";
+
+	recognition.onresult = (event) => {
+		const result = event.results[event.results.length - 1];
+		if (!result.isFinal || !form()) {
+			return;
+		}
+
+		form().elements.phrase.value = result[0].transcript.trim();
+		form().dispatchEvent(new CustomEvent("voice-command"));
+	};
+
+	// Browsers stop listening after a while of silence, so keep going until
+	// the cook turns it off.
+	recognition.onend = () => {
+		if (listening) {
+			recognition.start();
+		}
+	};
+
+	recognition.onerror = (event) => {
+		if (event.error === "not-allowed" || event.error === "service-not-allowed") {
+			stop();
+		}
+	};
+
+	listening = true;
+	recognition.start();
+	render();
+}
+
+function stop() {
+	listening = false;
+	recognition?.stop();
+	recognition = null;
+	render();
+}
+
+if (Recognition) {
+	document.addEventListener("click", (event) => {
+		if (event.target.closest("#voice-toggle")) {
+			listening ? stop() : start();
+		}
+	});
+
+	// Steps are swapped in by patching #main, which brings back a hidden form.
+	new MutationObserver(render).observe(document.documentElement, { childList: true, subtree: true });
+	document.addEventListener("DOMContentLoaded", render);
+}
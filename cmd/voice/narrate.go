@@ -0,0 +1,126 @@
+package voice
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/i18n"
+	"cooking-with-datastar/cmd/recipes"
+	"encoding/xml"
+	"strings"
+)
+
+// Narration is what to read aloud at the cook's current position in the
+// recipe, and what comes after it.
+type Narration struct {
+	Current string
+	Next    string
+}
+
+func Narrate(ctx context.Context, r recipes.Recipe, s recipes.Step, gatheredIngredients map[string]bool, finishedTasks map[string]bool) Narration {
+	cm := r.GetCookingMethod()
+	cook := i18n.T(ctx, "narrate.then", strings.ToLower(i18n.Label(ctx, r, cm.Name)), cm.Description)
+	done := i18n.T(ctx, "narrate.done", i18n.Title(ctx, r))
+
+	// Tasks are read in the same order as they are drawn and exported.
+	// Recipes are validated when registered, so sorting cannot fail on one
+	// that is being cooked.
+	tasks, err := recipes.SortTasks(r.ListPrepTasks())
+	if err != nil {
+		tasks = r.ListPrepTasks()
+	}
+
+	switch s {
+	case recipes.Gather:
+		// Optional ingredients are left out, since gathering can finish
+		// without them.
+		remaining := []string{}
+		for _, g := range r.ListIngredientGroups() {
+			if !g.Optional() && !g.Gathered(gatheredIngredients) {
+				remaining = append(remaining, i18n.DescribeGroup(ctx, g))
+			}
+		}
+
+		next := cook
+		if len(tasks) > 0 {
+			next = i18n.T(ctx, "narrate.then", strings.ToLower(i18n.T(ctx, "step.prepare")), tasks[0].Description)
+		}
+
+		return Narration{i18n.T(ctx, "narrate.gather", joinList(ctx, remaining)), next}
+
+	case recipes.Prepare:
+		upcoming := []string{}
+		for _, t := range tasks {
+			if !finishedTasks[t.Name] && ready(t, finishedTasks) {
+				upcoming = append(upcoming, t.Description)
+			}
+		}
+
+		// Tasks that are waiting on the current one come after the ones that
+		// are ready now.
+		for _, t := range tasks {
+			if !finishedTasks[t.Name] && !ready(t, finishedTasks) {
+				upcoming = append(upcoming, t.Description)
+			}
+		}
+
+		upcoming = append(upcoming, cook)
+
+		if len(upcoming) == 1 {
+			return Narration{cook, done}
+		}
+
+		return Narration{upcoming[0], upcoming[1]}
+
+	case recipes.Cook:
+		return Narration{cm.Description, done}
+
+	default:
+		return Narration{done, ""}
+	}
+}
+
+// Text is a plain narration script, one line per item.
+func (n Narration) Text() string {
+	if n.Next == "" {
+		return n.Current + "\n"
+	}
+
+	return n.Current + "\n" + n.Next + "\n"
+}
+
+// SSML marks up the script for speech synthesis services, with a pause
+// between the current item and the next.
+func (n Narration) SSML(locale string) string {
+	var b strings.Builder
+
+	b.WriteString(`<?xml version="1.0"?>` + "\n")
+	b.WriteString(`<speak version="1.1" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="` + locale + `">`)
+
+	for i, line := range []string{n.Current, n.Next} {
+		if line == "" {
+			continue
+		}
+
+		if i > 0 {
+			b.WriteString(`<break time="700ms"/>`)
+		}
+
+		b.WriteString("<p>")
+		xml.EscapeText(&b, []byte(line))
+		b.WriteString("</p>")
+	}
+
+	b.WriteString("</speak>\n")
+
+	return b.String()
+}
+
+func joinList(ctx context.Context, items []string) string {
+	switch len(items) {
+	case 0:
+		return i18n.T(ctx, "narrate.nothing-left")
+	case 1:
+		return items[0]
+	default:
+		return i18n.T(ctx, "narrate.list", strings.Join(items[:len(items)-1], ", "), items[len(items)-1])
+	}
+}
@@ -0,0 +1,67 @@
+package voice_test
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/recipes"
+	"cooking-with-datastar/cmd/voice"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNarrate(t *testing.T) {
+	r := recipes.PulledPork
+
+	tests := []struct {
+		name     string
+		step     recipes.Step
+		finished map[string]bool
+		current  string
+		next     string
+	}{
+		{"first task", recipes.Prepare, map[string]bool{}, "Place pork roast in a slow cooker.", "Whisk ketchup"},
+		{"waiting task", recipes.Prepare, map[string]bool{"place": true, "combine": true}, "Pour the mixture", "Then "},
+		{"cook", recipes.Cook, map[string]bool{}, r.GetCookingMethod().Description, "Pulled pork is ready"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := voice.Narrate(context.Background(), r, tc.step, map[string]bool{}, tc.finished)
+
+			if !strings.HasPrefix(result.Current, tc.current) {
+				t.Logf("want '%s', got '%s'", tc.current, result.Current)
+				t.Fail()
+			}
+
+			if !strings.HasPrefix(result.Next, tc.next) {
+				t.Logf("want '%s', got '%s'", tc.next, result.Next)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestNarrateGatherInTaskOrder(t *testing.T) {
+	// The task that comes last in the file has to wait for the others, so it
+	// is not the one read out after gathering.
+	r := recipes.PulledPork
+	r.Tasks = slices.Clone(r.Tasks)
+	slices.Reverse(r.Tasks)
+
+	result := voice.Narrate(context.Background(), r, recipes.Gather, map[string]bool{}, map[string]bool{})
+
+	if strings.Contains(result.Next, "Pour the mixture") {
+		t.Logf("want a task that is ready first, got '%s'", result.Next)
+		t.Fail()
+	}
+}
+
+func TestNarrationSSML(t *testing.T) {
+	n := voice.Narration{"Salt & pepper", "Then <bake>."}
+
+	expected := `<p>Salt &amp; pepper</p><break time="700ms"/><p>Then &lt;bake&gt;.</p></speak>`
+	if result := n.SSML("en"); !strings.HasSuffix(strings.TrimSpace(result), expected) {
+		t.Logf("want '%s', got '%s'", expected, result)
+		t.Fail()
+	}
+}
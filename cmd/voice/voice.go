@@ -0,0 +1,318 @@
+// Package voice turns phrases spoken while cooking into the same actions as
+// the buttons and checkboxes on the recipe page.
+package voice
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/i18n"
+	"cooking-with-datastar/cmd/internal"
+	"cooking-with-datastar/cmd/recipes"
+	"errors"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+type Action int
+
+const (
+	// Next does whatever comes next in the current step.
+	Next Action = iota
+	Gather
+	FinishTask
+	StartTimer
+	// Read reads the current item aloud and ReadNext the one after it.
+	Read
+	ReadNext
+)
+
+// Timer is how far the cook countdown has got.
+type Timer int
+
+const (
+	TimerNotStarted Timer = iota
+	TimerRunning
+	TimerFinished
+)
+
+type Command struct {
+	Action Action
+	// Name is the ingredient or task the command is about, if any.
+	Name string
+}
+
+var ErrNotUnderstood = errors.New("not understood")
+
+var (
+	nextPhrases  = []string{"next", "next step", "next one", "done"}
+	timerPhrases = []string{"start timer", "start the timer", "start cooking"}
+	readPhrases  = []string{"read", "read it", "read step", "read the step", "repeat", "repeat that", "what now"}
+	// "What's next" loses its apostrophe in normalize.
+	readNextPhrases = []string{"read next", "read the next step", "whats next", "what is next"}
+	// Longer prefixes come first so that "done with" wins over "done".
+	gatherPrefixes = []string{"check off", "check", "gathered", "got", "have"}
+	finishPrefixes = []string{"done with", "finished with", "finished", "finish", "done"}
+	finishSuffixes = []string{"is done", "done", "is finished", "finished"}
+	leadingWords   = []string{"ok", "okay", "hey", "so", "im", "i", "am", "please"}
+	fillerWords    = []string{"the", "a", "an", "some", "and", "please", "i", "im", "am", "is", "with"}
+)
+
+// Parse matches a phrase against the recipe's ingredient and task names, so
+// "done with shred" finishes the shred task and "got the butter" checks off
+// butter.
+func Parse(r recipes.Recipe, phrase string) (Command, error) {
+	words := normalize(phrase)
+	for len(words) > 1 && slices.Contains(leadingWords, words[0]) {
+		words = words[1:]
+	}
+
+	text := strings.Join(words, " ")
+
+	if text == "" {
+		return Command{}, ErrNotUnderstood
+	}
+
+	if slices.Contains(nextPhrases, text) {
+		return Command{Next, ""}, nil
+	}
+
+	if slices.Contains(readPhrases, text) {
+		return Command{Read, ""}, nil
+	}
+
+	if slices.Contains(readNextPhrases, text) {
+		return Command{ReadNext, ""}, nil
+	}
+
+	method := strings.Join(normalize(r.GetCookingMethod().Name), " ")
+	if slices.Contains(timerPhrases, text) || text == "start "+method || text == "start the "+method {
+		return Command{StartTimer, ""}, nil
+	}
+
+	for _, p := range gatherPrefixes {
+		if rest, ok := cutWords(text, p); ok {
+			if name, ok := match(ingredientNames(r), rest); ok {
+				return Command{Gather, name}, nil
+			}
+		}
+	}
+
+	for _, p := range finishPrefixes {
+		if rest, ok := cutWords(text, p); ok {
+			if name, ok := match(taskNames(r), rest); ok {
+				return Command{FinishTask, name}, nil
+			}
+
+			// "Done with the butter" reads just as well as "got the butter".
+			if name, ok := match(ingredientNames(r), rest); ok {
+				return Command{Gather, name}, nil
+			}
+		}
+	}
+
+	for _, s := range finishSuffixes {
+		if rest, ok := strings.CutSuffix(text, " "+s); ok {
+			if name, ok := match(taskNames(r), rest); ok {
+				return Command{FinishTask, name}, nil
+			}
+		}
+	}
+
+	return Command{}, ErrNotUnderstood
+}
+
+// Resolve turns the command into the ingredient to check off, the task to
+// finish or the timer to start, with the same dependency checks as the recipe
+// page. Next becomes whichever of those comes next. The timer is only started
+// once, since each start opens another countdown.
+func (c Command) Resolve(ctx context.Context, r recipes.Recipe, s recipes.Step, gatheredIngredients map[string]bool, finishedTasks map[string]bool, timer Timer) (Command, error) {
+	switch c.Action {
+	case Next:
+		switch s {
+		case recipes.Gather:
+			// Next skips optional ingredients and checks the first of a
+			// substitution group.
+			for _, g := range r.ListIngredientGroups() {
+				if !g.Optional() && !g.Gathered(gatheredIngredients) {
+					return Command{Gather, g.Ingredients[0].Name}, nil
+				}
+			}
+
+		case recipes.Prepare:
+			for _, t := range r.ListPrepTasks() {
+				if !finishedTasks[t.Name] && ready(t, finishedTasks) {
+					return Command{FinishTask, t.Name}, nil
+				}
+			}
+
+		case recipes.Cook:
+			return startTimer(ctx, r, timer)
+		}
+
+		return Command{}, errors.New(i18n.T(ctx, "voice.nothing-left"))
+
+	case Gather:
+		if s != recipes.Gather {
+			return Command{}, errors.New(i18n.T(ctx, "voice.already-gathered"))
+		}
+
+		if gatheredIngredients[c.Name] {
+			return Command{}, errors.New(i18n.T(ctx, "voice.already-checked", internal.ToStartCase(c.Name)))
+		}
+
+		return c, nil
+
+	case FinishTask:
+		if s != recipes.Prepare {
+			return Command{}, errors.New(i18n.T(ctx, "voice.not-prep"))
+		}
+
+		task, err := recipes.ParseTask(r, c.Name)
+		if err != nil {
+			return Command{}, err
+		}
+
+		if finishedTasks[task.Name] {
+			return Command{}, errors.New(i18n.T(ctx, "voice.already-done", i18n.Label(ctx, r, task.Name)))
+		}
+
+		if !ready(task, finishedTasks) {
+			return Command{}, errors.New(i18n.T(ctx, "voice.waiting", i18n.Label(ctx, r, task.Name), strings.Join(labels(ctx, r, task.Dependencies), ", ")))
+		}
+
+		return Command{FinishTask, task.Name}, nil
+
+	case StartTimer:
+		if s != recipes.Cook {
+			return Command{}, errors.New(i18n.T(ctx, "voice.not-cook"))
+		}
+
+		return startTimer(ctx, r, timer)
+
+	default:
+		return Command{}, ErrNotUnderstood
+	}
+}
+
+func startTimer(ctx context.Context, r recipes.Recipe, timer Timer) (Command, error) {
+	switch timer {
+	case TimerRunning:
+		return Command{}, errors.New(i18n.T(ctx, "voice.timer-running"))
+
+	case TimerFinished:
+		return Command{}, errors.New(i18n.T(ctx, "voice.cooked", i18n.Title(ctx, r)))
+	}
+
+	return Command{StartTimer, ""}, nil
+}
+
+func labels(ctx context.Context, r recipes.Recipe, names []string) []string {
+	l := []string{}
+	for _, n := range names {
+		l = append(l, i18n.Label(ctx, r, n))
+	}
+
+	return l
+}
+
+func ready(t recipes.Task, finishedTasks map[string]bool) bool {
+	for _, d := range t.Dependencies {
+		if !finishedTasks[d] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func ingredientNames(r recipes.Recipe) []string {
+	names := []string{}
+	for _, i := range r.ListIngredients() {
+		names = append(names, i.Name)
+	}
+
+	return names
+}
+
+func taskNames(r recipes.Recipe) []string {
+	names := []string{}
+	for _, t := range r.ListPrepTasks() {
+		names = append(names, t.Name)
+	}
+
+	return names
+}
+
+// match prefers a name whose every word was spoken, e.g. "cook the chicken",
+// and falls back to the only name containing a spoken word, e.g. "chicken".
+func match(names []string, spoken string) (string, bool) {
+	words := []string{}
+	for _, w := range strings.Fields(spoken) {
+		if !slices.Contains(fillerWords, w) {
+			words = append(words, w)
+		}
+	}
+
+	if len(words) == 0 {
+		return "", false
+	}
+
+	best := ""
+	bestLen := 0
+	partial := []string{}
+
+	for _, n := range names {
+		nameWords := normalize(n)
+
+		all := true
+		some := false
+		for _, w := range nameWords {
+			if slices.Contains(words, w) {
+				some = true
+			} else if !slices.Contains(fillerWords, w) {
+				all = false
+			}
+		}
+
+		if all && len(nameWords) > bestLen {
+			best = n
+			bestLen = len(nameWords)
+		}
+
+		if some {
+			partial = append(partial, n)
+		}
+	}
+
+	if best != "" {
+		return best, true
+	}
+
+	if len(partial) == 1 {
+		return partial[0], true
+	}
+
+	return "", false
+}
+
+func cutWords(text string, prefix string) (string, bool) {
+	rest, ok := strings.CutPrefix(text, prefix+" ")
+	return rest, ok
+}
+
+// normalize lowercases a phrase or name and splits it into words, dropping
+// punctuation and treating hyphens as spaces.
+func normalize(s string) []string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r):
+			return unicode.ToLower(r)
+		case r == internal.HYPHEN || r == internal.UNDERSCORE:
+			return ' '
+		default:
+			return -1
+		}
+	}, s)
+
+	return strings.Fields(s)
+}
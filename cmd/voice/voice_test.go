@@ -0,0 +1,94 @@
+package voice_test
+
+import (
+	"context"
+	"cooking-with-datastar/cmd/recipes"
+	"cooking-with-datastar/cmd/voice"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		phrase   string
+		expected voice.Command
+	}{
+		{"Next", voice.Command{voice.Next, ""}},
+		{"OK, next step.", voice.Command{voice.Next, ""}},
+		{"start timer", voice.Command{voice.StartTimer, ""}},
+		{"Start the bake", voice.Command{voice.StartTimer, ""}},
+		{"done with shred", voice.Command{voice.FinishTask, "shred"}},
+		{"I'm done with cook the chicken", voice.Command{voice.FinishTask, "cook-the-chicken"}},
+		{"finished heating the oven", voice.Command{voice.FinishTask, "heat-the-oven"}},
+		{"shred is done", voice.Command{voice.FinishTask, "shred"}},
+		{"got the cream cheese", voice.Command{voice.Gather, "cream-cheese"}},
+		{"check off ranch", voice.Command{voice.Gather, "ranch-dressing"}},
+		{"done with the chicken", voice.Command{voice.FinishTask, "cook-the-chicken"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.phrase, func(t *testing.T) {
+			result, err := voice.Parse(recipes.BuffaloChickenDip, tc.phrase)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result != tc.expected {
+				t.Logf("want '%v', got '%v'", tc.expected, result)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestParseNotUnderstood(t *testing.T) {
+	for _, phrase := range []string{"", "play some music", "done with the dishes"} {
+		t.Run(phrase, func(t *testing.T) {
+			_, err := voice.Parse(recipes.BuffaloChickenDip, phrase)
+			if !errors.Is(err, voice.ErrNotUnderstood) {
+				t.Logf("want '%v', got '%v'", voice.ErrNotUnderstood, err)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	r := recipes.BuffaloChickenDip
+
+	tests := []struct {
+		name     string
+		command  voice.Command
+		step     recipes.Step
+		gathered map[string]bool
+		finished map[string]bool
+		timer    voice.Timer
+		expected voice.Command
+		wantErr  bool
+	}{
+		{"next ingredient", voice.Command{voice.Next, ""}, recipes.Gather, map[string]bool{"chicken": true}, nil, voice.TimerNotStarted, voice.Command{voice.Gather, "cream-cheese"}, false},
+		{"next task", voice.Command{voice.Next, ""}, recipes.Prepare, nil, map[string]bool{"cook-the-chicken": true}, voice.TimerNotStarted, voice.Command{voice.FinishTask, "shred"}, false},
+		{"next cook", voice.Command{voice.Next, ""}, recipes.Cook, nil, nil, voice.TimerNotStarted, voice.Command{voice.StartTimer, ""}, false},
+		{"ingredient", voice.Command{voice.Gather, "hot-sauce"}, recipes.Gather, nil, nil, voice.TimerNotStarted, voice.Command{voice.Gather, "hot-sauce"}, false},
+		{"task", voice.Command{voice.FinishTask, "shred"}, recipes.Prepare, nil, map[string]bool{"cook-the-chicken": true}, voice.TimerNotStarted, voice.Command{voice.FinishTask, "shred"}, false},
+		{"waiting task", voice.Command{voice.FinishTask, "shred"}, recipes.Prepare, nil, map[string]bool{}, voice.TimerNotStarted, voice.Command{}, true},
+		{"wrong step", voice.Command{voice.StartTimer, ""}, recipes.Gather, nil, nil, voice.TimerNotStarted, voice.Command{}, true},
+		{"timer running", voice.Command{voice.StartTimer, ""}, recipes.Cook, nil, nil, voice.TimerRunning, voice.Command{}, true},
+		{"next with the timer running", voice.Command{voice.Next, ""}, recipes.Cook, nil, nil, voice.TimerRunning, voice.Command{}, true},
+		{"cooked", voice.Command{voice.StartTimer, ""}, recipes.Cook, nil, nil, voice.TimerFinished, voice.Command{}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.command.Resolve(context.Background(), r, tc.step, tc.gathered, tc.finished, tc.timer)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("want error %v, got '%v'", tc.wantErr, err)
+			}
+
+			if result != tc.expected {
+				t.Logf("want '%v', got '%v'", tc.expected, result)
+				t.Fail()
+			}
+		})
+	}
+}
@@ -5,13 +5,13 @@ go 1.24.0
 tool github.com/a-h/templ/cmd/templ
 
 require (
+	github.com/CAFxX/httpcompression v0.0.9
 	github.com/a-h/templ v0.3.920
 	github.com/starfederation/datastar-go v1.0.1
 	github.com/matoous/go-nanoid/v2 v2.1.0
 )
 
 require (
-	github.com/CAFxX/httpcompression v0.0.9 // indirect
 	github.com/a-h/parse v0.0.0-20250122154542-74294addb73e // indirect
 	github.com/andybalholm/brotli v1.1.0 // indirect
 	github.com/cenkalti/backoff/v4 v4.3.0 // indirect
@@ -0,0 +1,11 @@
+#!/bin/sh
+# Fetch the pinned frontend assets listed in cmd/internal/frontend_assets.txt
+# into the embedded static directory so the app works without internet
+# access. Rebuild afterwards.
+set -e
+
+grep -v '^#' cmd/internal/frontend_assets.txt | while read -r name file url; do
+	mkdir -p "cmd/$(dirname "$file")"
+	echo "$name"
+	curl -fsSL -o "cmd/$file" "$url"
+done